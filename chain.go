@@ -0,0 +1,229 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// 证书链问题分类
+const (
+	ChainHostnameMismatch    = "hostname_mismatch"
+	ChainLeafExpired         = "leaf_expired"
+	ChainIntermediateExpired = "intermediate_expired"
+	ChainMissingIntermediate = "missing_intermediate"
+	ChainUntrustedRoot       = "untrusted_root"
+	ChainInvalid             = "chain_invalid"
+)
+
+// 查找签发者的最大层数，防止异常证书链循环
+const chainMaxDepth = 10
+
+// 证书链问题在通知中显示的名称
+var chainProblemText = map[string]string{
+	ChainHostnameMismatch:    "域名不匹配",
+	ChainLeafExpired:         "证书已过期",
+	ChainIntermediateExpired: "中间证书已过期",
+	ChainMissingIntermediate: "缺少中间证书",
+	ChainUntrustedRoot:       "根证书不受信任",
+	ChainInvalid:             "证书链校验失败",
+}
+
+// 校验证书链使用的根证书，nil表示使用系统根证书，配置probe.ca_file时追加自定义CA(内网自签CA)
+var probeRootCAs *x509.CertPool
+
+// 定义证书链中的单个证书
+type ChainCertificate struct {
+	Subject    string
+	Issuer     string
+	NotBefore  time.Time
+	NotAfter   time.Time
+	IsCA       bool
+	SelfSigned bool
+}
+
+// 定义单个目标的证书链分析结果，Problems为空表示证书链正常
+type ChainReport struct {
+	Target       string
+	Certificates []ChainCertificate
+	Problems     []string
+}
+
+/**
+* 加载probe.ca_file配置的自定义CA，与系统根证书合并
+ * @param caFile
+ * @return *x509.CertPool
+ * @return error
+*/
+func LoadRootCAs(caFile string) (pool *x509.CertPool, _err error) {
+	if caFile == "" {
+		return nil, nil
+	}
+
+	pool, _err = x509.SystemCertPool()
+	if _err != nil {
+		pool = x509.NewCertPool()
+	}
+	content, _err := ioutil.ReadFile(caFile)
+	if _err != nil {
+		return nil, _err
+	}
+	if !pool.AppendCertsFromPEM(content) {
+		return nil, errors.New("CA文件中没有可用的PEM证书: " + caFile)
+	}
+	return pool, nil
+}
+
+/**
+* 按浏览器的方式校验服务端证书(证书链+域名)
+ * @param certs
+ * @param host
+ * @return error
+*/
+func verifyPeerCertificates(certs []*x509.Certificate, host string) error {
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		DNSName:       host,
+		Roots:         probeRootCAs,
+		Intermediates: intermediates,
+	})
+	return err
+}
+
+/**
+* 分析服务端返回的证书链，区分域名不匹配、证书过期、中间证书过期、缺少中间证书、根证书不受信任等问题
+ * @param target
+ * @param host
+ * @param certs
+ * @param now
+ * @return ChainReport
+*/
+func analyzeChain(target string, host string, certs []*x509.Certificate, now time.Time) ChainReport {
+	report := ChainReport{Target: target}
+	if len(certs) == 0 {
+		return report
+	}
+
+	for i, cert := range certs {
+		selfSigned := isSelfSigned(cert)
+		report.Certificates = append(report.Certificates, ChainCertificate{
+			Subject:    cert.Subject.String(),
+			Issuer:     cert.Issuer.String(),
+			NotBefore:  cert.NotBefore,
+			NotAfter:   cert.NotAfter,
+			IsCA:       cert.IsCA,
+			SelfSigned: selfSigned,
+		})
+		infologger.Printf("Certificate chain for %s [%d] subject=%s issuer=%s notBefore=%s notAfter=%s",
+			target, i, cert.Subject, cert.Issuer, cert.NotBefore.Format("2006-01-02"), cert.NotAfter.Format("2006-01-02"))
+	}
+
+	leaf := certs[0]
+	if leaf.VerifyHostname(host) != nil {
+		report.Problems = append(report.Problems, ChainHostnameMismatch)
+	}
+	if now.After(leaf.NotAfter) || now.Before(leaf.NotBefore) {
+		report.Problems = append(report.Problems, ChainLeafExpired)
+	}
+
+	// 服务端一起发送的自签根证书过期不影响客户端，只检查中间证书
+	for _, cert := range certs[1:] {
+		if !isSelfSigned(cert) && (now.After(cert.NotAfter) || now.Before(cert.NotBefore)) {
+			report.Problems = append(report.Problems, ChainIntermediateExpired)
+			break
+		}
+	}
+
+	// 在叶子证书有效期内校验证书链结构，避免叶子证书过期掩盖缺少中间证书等问题
+	verifyTime := now
+	if now.After(leaf.NotAfter) {
+		verifyTime = leaf.NotAfter.Add(-time.Minute)
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         probeRootCAs,
+		Intermediates: intermediates,
+		CurrentTime:   verifyTime,
+	})
+
+	var unknownAuthority x509.UnknownAuthorityError
+	var invalidErr x509.CertificateInvalidError
+	switch {
+	case err == nil:
+	case errors.As(err, &unknownAuthority):
+		// 从叶子证书开始沿着签发者往上找，链的顶端是自签证书说明链是完整的但根证书不受信任，否则是缺少中间证书
+		if isSelfSigned(chainTop(certs)) {
+			report.Problems = append(report.Problems, ChainUntrustedRoot)
+		} else {
+			report.Problems = append(report.Problems, ChainMissingIntermediate)
+		}
+	case errors.As(err, &invalidErr) && invalidErr.Reason == x509.Expired:
+		// 过期问题上面已经单独分类
+		if !containsFold(report.Problems, ChainIntermediateExpired) && !containsFold(report.Problems, ChainLeafExpired) {
+			report.Problems = append(report.Problems, ChainIntermediateExpired)
+		}
+	default:
+		report.Problems = append(report.Problems, ChainInvalid)
+	}
+	return report
+}
+
+/**
+* 从叶子证书开始在服务端返回的证书中查找签发者，返回能找到的最上层证书
+ * @param certs
+ * @return *x509.Certificate
+*/
+func chainTop(certs []*x509.Certificate) *x509.Certificate {
+	top := certs[0]
+	for depth := 0; depth < chainMaxDepth && !isSelfSigned(top); depth++ {
+		var issuer *x509.Certificate
+		for _, cert := range certs[1:] {
+			if cert != top && bytes.Equal(top.RawIssuer, cert.RawSubject) && top.CheckSignatureFrom(cert) == nil {
+				issuer = cert
+				break
+			}
+		}
+		if issuer == nil {
+			break
+		}
+		top = issuer
+	}
+	return top
+}
+
+/**
+* 是否是自签证书
+ * @param cert
+ * @return bool
+*/
+func isSelfSigned(cert *x509.Certificate) bool {
+	// 不用CheckSignatureFrom，它要求签发者是CA，自签的叶子证书会判断错误
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) &&
+		cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
+
+/**
+* 证书链问题转换成通知中显示的文字
+ * @param problems
+ * @return string
+*/
+func chainProblemsText(problems []string) string {
+	var texts []string
+	for _, problem := range problems {
+		texts = append(texts, chainProblemText[problem])
+	}
+	return strings.Join(texts, "、")
+}
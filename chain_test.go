@@ -0,0 +1,82 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"crypto/x509"
+	"reflect"
+	"testing"
+	"time"
+)
+
+/**
+* 按服务端返回的证书链对问题分类
+ * @param t
+*/
+func TestAnalyzeChain(t *testing.T) {
+	now := time.Now()
+	longAgo, farAway := now.Add(-365*24*time.Hour), now.Add(365*24*time.Hour)
+	host := "www.example.com"
+
+	root := newTestCert(t, "Test Root", nil, true, longAgo, farAway, nil)
+	intermediate := newTestCert(t, "Test Intermediate", nil, true, longAgo, farAway, &root)
+	expiredIntermediate := newTestCert(t, "Expired Intermediate", nil, true, longAgo, now.Add(-24*time.Hour), &root)
+	leaf := newTestCert(t, host, []string{host}, false, now.Add(-time.Hour), now.Add(90*24*time.Hour), &intermediate)
+	otherLeaf := newTestCert(t, "other.example.com", []string{"other.example.com"}, false, now.Add(-time.Hour), now.Add(90*24*time.Hour), &intermediate)
+	expiredLeaf := newTestCert(t, host, []string{host}, false, now.Add(-48*time.Hour), now.Add(-24*time.Hour), &intermediate)
+	leafOfExpired := newTestCert(t, host, []string{host}, false, now.Add(-time.Hour), now.Add(90*24*time.Hour), &expiredIntermediate)
+
+	untrustedRoot := newTestCert(t, "Untrusted Root", nil, true, longAgo, farAway, nil)
+	untrustedIntermediate := newTestCert(t, "Untrusted Intermediate", nil, true, longAgo, farAway, &untrustedRoot)
+	untrustedLeaf := newTestCert(t, host, []string{host}, false, now.Add(-time.Hour), now.Add(90*24*time.Hour), &untrustedIntermediate)
+	selfSigned := newTestCert(t, host, []string{host}, false, now.Add(-time.Hour), now.Add(90*24*time.Hour), nil)
+
+	trustTestRoot(t, root)
+
+	tests := []struct {
+		name     string
+		chain    []testCert
+		problems []string
+	}{
+		{"证书链正常", []testCert{leaf, intermediate}, nil},
+		{"服务端同时返回根证书", []testCert{leaf, intermediate, root}, nil},
+		{"域名不匹配", []testCert{otherLeaf, intermediate}, []string{ChainHostnameMismatch}},
+		// 叶子证书过期不能掩盖证书链本身是完整的
+		{"叶子证书过期", []testCert{expiredLeaf, intermediate}, []string{ChainLeafExpired}},
+		{"叶子证书过期并且缺少中间证书", []testCert{expiredLeaf}, []string{ChainLeafExpired, ChainMissingIntermediate}},
+		{"中间证书过期", []testCert{leafOfExpired, expiredIntermediate}, []string{ChainIntermediateExpired}},
+		{"缺少中间证书", []testCert{leaf}, []string{ChainMissingIntermediate}},
+		{"根证书不受信任", []testCert{untrustedLeaf, untrustedIntermediate, untrustedRoot}, []string{ChainUntrustedRoot}},
+		{"自签证书", []testCert{selfSigned}, []string{ChainUntrustedRoot}},
+	}
+	for _, test := range tests {
+		var certs []*x509.Certificate
+		for _, item := range test.chain {
+			certs = append(certs, item.cert)
+		}
+		report := analyzeChain(host, host, certs, now)
+		if !reflect.DeepEqual(report.Problems, test.problems) {
+			t.Errorf("%s: Problems = %v, want %v", test.name, report.Problems, test.problems)
+		}
+		if len(report.Certificates) != len(certs) {
+			t.Errorf("%s: Certificates有%d张, want %d", test.name, len(report.Certificates), len(certs))
+		}
+
+		// 握手后的证书校验与分析结果一致：没有问题时校验通过
+		if err := verifyPeerCertificates(certs, host); (err == nil) != (len(test.problems) == 0) {
+			t.Errorf("%s: verifyPeerCertificates = %v, 与分析结果%v不一致", test.name, err, test.problems)
+		}
+	}
+}
+
+/**
+* 证书链问题转换成通知中的文字
+ * @param t
+*/
+func TestChainProblemsText(t *testing.T) {
+	if got, want := chainProblemsText([]string{ChainLeafExpired, ChainMissingIntermediate}), "证书已过期、缺少中间证书"; got != want {
+		t.Fatalf("chainProblemsText = %s, want %s", got, want)
+	}
+}
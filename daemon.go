@@ -0,0 +1,161 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/spf13/viper"
+)
+
+// 各阶段共用域名清单、探测结果等全局变量，同一时间只允许一个阶段执行
+var stageMutex sync.Mutex
+
+// 是否以常驻进程方式运行，只有常驻进程会通过/metrics暴露ssl_cert_*指标
+var daemonMode bool
+
+/**
+* 以常驻进程方式运行，SDK客户端只初始化一次，按daemon.schedules中的cron表达式定时执行各阶段
+* 同一个阶段上一次还没执行完时跳过本次，不同阶段排队执行；收到SIGTERM/SIGINT后等待正在执行的阶段完成再退出
+ * @return error
+*/
+func runDaemon() (_err error) {
+	daemonMode = true
+
+	// 加载配置文件
+	_err = GetConfig()
+	if _err != nil {
+		errlogger.Printf("加载配置文件失败: %v", _err)
+		return _err
+	} else {
+		infologger.Printf("加载配置文件成功")
+	}
+
+	// 初始化各DNS服务商SDK，常驻进程中复用
+	providers, _err := ClientInit()
+	if _err != nil {
+		setpStatusMap["initStatus"] = []string{failText, failColor}
+		errlogger.Printf("初始化DNS服务商SDK:执行失败: %v", _err)
+		return _err
+	}
+	setpStatusMap["initStatus"] = []string{successText, successColor}
+
+	// 启动/metrics和HTTP服务发现接口，服务发现先返回上一次生成的targets文件中的目标
+	if err := seedTargetGroups(); err != nil {
+		errlogger.Printf("加载已有targets文件异常: %v", err)
+	}
+	stopServers, _err := startServers()
+	if _err != nil {
+		errlogger.Printf("启动HTTP服务失败: %v", _err)
+		return _err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		stopServers(ctx)
+	}()
+	updateStepMetrics(setpStatusMap)
+
+	stages := []struct {
+		name string
+		run  func() error
+	}{
+		{"inventory", func() error { return SyncInventory(providers) }},
+		{"probe", ProbeHttpsDomains},
+		{"notify", func() error {
+			if err := Notice(httpsDomainSum, setpStatusMap); err != nil {
+				return err
+			}
+			return NoticeTeams()
+		}},
+	}
+
+	// 定时执行和启动时执行共用同一个包装后的任务，同一个阶段上一次还没执行完时跳过
+	logger := cron.PrintfLogger(infologger)
+	scheduler := cron.New(cron.WithLogger(logger))
+	jobs := make(map[string]cron.Job)
+	for _, stage := range stages {
+		jobs[stage.name] = cron.NewChain(cron.Recover(logger), cron.SkipIfStillRunning(logger)).Then(cron.FuncJob(stageJob(stage.name, stage.run)))
+		spec := viper.GetString("daemon.schedules." + stage.name)
+		if spec == "" {
+			infologger.Printf("daemon.schedules.%s没有配置，不定时执行", stage.name)
+			continue
+		}
+		if _, _err = scheduler.AddJob(spec, jobs[stage.name]); _err != nil {
+			return fmt.Errorf("daemon.schedules.%s格式错误 %s: %v", stage.name, spec, _err)
+		}
+		infologger.Printf("阶段%s按%s定时执行", stage.name, spec)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	scheduler.Start()
+	infologger.Printf("常驻进程已启动，pid: %d", os.Getpid())
+
+	// 启动时先同步一次清单并探测，不用等到第一个调度时间点；在后台执行，执行期间收到退出信号同样按shutdown_timeout等待
+	var initialRun sync.WaitGroup
+	if viper.GetBool("daemon.run_on_start") {
+		initialRun.Add(1)
+		go func() {
+			defer initialRun.Done()
+			for _, name := range []string{"inventory", "probe"} {
+				if ctx.Err() != nil {
+					return
+				}
+				jobs[name].Run()
+			}
+		}()
+	}
+	<-ctx.Done()
+
+	// 不再触发新的任务，等待正在执行的阶段完成
+	infologger.Printf("收到退出信号，等待正在执行的阶段完成")
+	timeout := time.Duration(viper.GetInt("daemon.shutdown_timeout")) * time.Second
+	done := make(chan struct{})
+	go func() {
+		<-scheduler.Stop().Done()
+		initialRun.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		infologger.Printf("常驻进程已退出")
+	case <-time.After(timeout):
+		errlogger.Printf("等待正在执行的阶段超过%s，强制退出", timeout)
+	}
+	return nil
+}
+
+/**
+* 把阶段包装成定时任务，执行前获取全局锁，记录耗时和执行结果，并更新执行状态指标
+ * @param name
+ * @param run
+ * @return func()
+*/
+func stageJob(name string, run func() error) func() {
+	return func() {
+		stageMutex.Lock()
+		defer stageMutex.Unlock()
+
+		start := time.Now()
+		infologger.Printf("阶段%s开始执行", name)
+		err := run()
+		observeStage(name, time.Since(start))
+		updateStepMetrics(setpStatusMap)
+		if err != nil {
+			errlogger.Printf("阶段%s:执行失败: %v", name, err)
+			return
+		}
+		infologger.Printf("阶段%s:执行完成，耗时%s", name, time.Since(start).Round(time.Millisecond))
+	}
+}
@@ -0,0 +1,128 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// 证书到期分级
+const (
+	CertLevelOK       = "ok"
+	CertLevelWarning  = "warning"
+	CertLevelCritical = "critical"
+	CertLevelExpired  = "expired"
+)
+
+// 定义单个目标的证书到期检查结果
+type CertExpiry struct {
+	Target   string
+	NotAfter time.Time
+	DaysLeft int
+	Level    string
+}
+
+/**
+* 根据告警阈值对证书到期时间分级
+ * @param target
+ * @param notAfter
+ * @param now
+ * @param warningDays
+ * @param criticalDays
+ * @return CertExpiry
+*/
+func classifyExpiry(target string, notAfter time.Time, now time.Time, warningDays int, criticalDays int) CertExpiry {
+	result := CertExpiry{
+		Target:   target,
+		NotAfter: notAfter,
+		DaysLeft: daysLeft(notAfter, now),
+		Level:    CertLevelOK,
+	}
+
+	switch {
+	case !now.Before(notAfter):
+		result.Level = CertLevelExpired
+	case result.DaysLeft <= criticalDays:
+		result.Level = CertLevelCritical
+	case result.DaysLeft <= warningDays:
+		result.Level = CertLevelWarning
+	}
+	return result
+}
+
+/**
+* 证书剩余天数，不足一天按0天计算，已过期为负数
+ * @param notAfter
+ * @param now
+ * @return int
+*/
+func daysLeft(notAfter time.Time, now time.Time) int {
+	return int(math.Floor(notAfter.Sub(now).Hours() / 24))
+}
+
+/**
+* 从握手错误中取出已过期的证书，不是证书过期导致的错误返回nil
+ * @param err
+ * @return *x509.Certificate
+*/
+func expiredCertFromError(err error) *x509.Certificate {
+	var invalidErr x509.CertificateInvalidError
+	if errors.As(err, &invalidErr) && invalidErr.Reason == x509.Expired {
+		return invalidErr.Cert
+	}
+	return nil
+}
+
+/**
+* 生成通知中的证书到期列表，已过期的排在最前面，其余按剩余天数升序
+ * @param expirySlice
+ * @return string
+*/
+func expiryNoticeContent(expirySlice []CertExpiry) string {
+	if len(expirySlice) == 0 {
+		return ""
+	}
+
+	sorted := append([]CertExpiry(nil), expirySlice...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].NotAfter.Before(sorted[j].NotAfter)
+	})
+
+	var expiredSum, criticalSum, warningSum int
+	for _, item := range sorted {
+		switch item.Level {
+		case CertLevelExpired:
+			expiredSum++
+		case CertLevelCritical:
+			criticalSum++
+		case CertLevelWarning:
+			warningSum++
+		}
+	}
+
+	content := fmt.Sprintf(`
+
+		> 【证书到期提醒】已过期<font color="red">%d条</font>，紧急<font color="red">%d条</font>，即将到期<font color="yellow">%d条</font>`,
+		expiredSum, criticalSum, warningSum)
+	for _, item := range sorted {
+		switch item.Level {
+		case CertLevelExpired:
+			content += fmt.Sprintf(`
+		> <font color="red">已过期</font> %s 到期%s(已过期%d天)`, item.Target, item.NotAfter.Format("2006-01-02"), -item.DaysLeft)
+		case CertLevelCritical:
+			content += fmt.Sprintf(`
+		> <font color="red">紧急</font> %s 到期%s(剩余%d天)`, item.Target, item.NotAfter.Format("2006-01-02"), item.DaysLeft)
+		case CertLevelWarning:
+			content += fmt.Sprintf(`
+		> <font color="yellow">即将到期</font> %s 到期%s(剩余%d天)`, item.Target, item.NotAfter.Format("2006-01-02"), item.DaysLeft)
+		}
+	}
+	return content
+}
@@ -0,0 +1,97 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+/**
+* 按默认阈值(30天告警、7天紧急)对到期时间分级，边界值按剩余整天数计算
+ * @param t
+*/
+func TestClassifyExpiry(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		notAfter time.Time
+		daysLeft int
+		level    string
+	}{
+		{now.Add(90 * 24 * time.Hour), 90, CertLevelOK},
+		{now.Add(31 * 24 * time.Hour), 31, CertLevelOK},
+		{now.Add(30*24*time.Hour + time.Hour), 30, CertLevelWarning},
+		{now.Add(8 * 24 * time.Hour), 8, CertLevelWarning},
+		{now.Add(7 * 24 * time.Hour), 7, CertLevelCritical},
+		// 不足一天按0天计算
+		{now.Add(time.Hour), 0, CertLevelCritical},
+		// 到期时间点本身已经算过期
+		{now, 0, CertLevelExpired},
+		{now.Add(-time.Hour), -1, CertLevelExpired},
+		{now.Add(-3 * 24 * time.Hour), -3, CertLevelExpired},
+	}
+	for _, test := range tests {
+		got := classifyExpiry("www.example.com", test.notAfter, now, 30, 7)
+		if got.DaysLeft != test.daysLeft || got.Level != test.level {
+			t.Errorf("classifyExpiry(%s) = %d天 %s, want %d天 %s", test.notAfter, got.DaysLeft, got.Level, test.daysLeft, test.level)
+		}
+	}
+}
+
+/**
+* 只有证书过期导致的校验错误才能取出过期证书
+ * @param t
+*/
+func TestExpiredCertFromError(t *testing.T) {
+	cert := &x509.Certificate{}
+	expiredErr := &ProbeError{Category: ProbeErrCertInvalid, Message: "证书校验异常", Err: x509.CertificateInvalidError{Cert: cert, Reason: x509.Expired}}
+	if expiredCertFromError(expiredErr) != cert {
+		t.Error("证书过期的错误应该返回过期证书")
+	}
+	if expiredCertFromError(fmt.Errorf("所有IP探测失败(1个): %w", expiredErr)) != cert {
+		t.Error("包装后的证书过期错误也要能识别")
+	}
+
+	for _, err := range []error{
+		x509.CertificateInvalidError{Cert: cert, Reason: x509.NotAuthorizedToSign},
+		x509.HostnameError{Certificate: cert, Host: "www.example.com"},
+		errors.New("connection refused"),
+		nil,
+	} {
+		if expiredCertFromError(err) != nil {
+			t.Errorf("expiredCertFromError(%v)应该返回nil", err)
+		}
+	}
+}
+
+/**
+* 通知中的到期列表按到期时间升序，已过期的排在最前面
+ * @param t
+*/
+func TestExpiryNoticeContent(t *testing.T) {
+	if expiryNoticeContent(nil) != "" {
+		t.Fatal("没有到期证书时不输出")
+	}
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	content := expiryNoticeContent([]CertExpiry{
+		classifyExpiry("warning.example.com", now.Add(20*24*time.Hour), now, 30, 7),
+		classifyExpiry("expired.example.com", now.Add(-2*24*time.Hour), now, 30, 7),
+		classifyExpiry("critical.example.com", now.Add(3*24*time.Hour), now, 30, 7),
+	})
+	if !strings.Contains(content, `已过期<font color="red">1条</font>，紧急<font color="red">1条</font>，即将到期<font color="yellow">1条</font>`) {
+		t.Fatalf("汇总数量错误: %s", content)
+	}
+	expired := strings.Index(content, "expired.example.com 到期2026-10-14(已过期2天)")
+	critical := strings.Index(content, "critical.example.com 到期2026-10-19(剩余3天)")
+	warning := strings.Index(content, "warning.example.com 到期2026-11-05(剩余20天)")
+	if expired < 0 || critical < 0 || warning < 0 || !(expired < critical && critical < warning) {
+		t.Fatalf("到期列表顺序错误: %s", content)
+	}
+}
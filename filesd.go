@@ -0,0 +1,223 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// 没有配置targets.labels时的默认标签，与原来写死的标签一致
+var defaultTargetLabels = map[string]string{
+	"group":      "web",
+	"department": "test-auto",
+}
+
+// 定义Prometheus file_sd中的一组目标
+type TargetGroup struct {
+	Targets []string          `json:"targets" yaml:"targets"`
+	Labels  map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+}
+
+// 定义按服务商/域名/主机给目标追加标签的规则，provider、zone、host为空表示不限制，zone和host支持glob
+type TargetLabelRule struct {
+	Provider string            `mapstructure:"provider"`
+	Zone     string            `mapstructure:"zone"`
+	Host     string            `mapstructure:"host"`
+	Labels   map[string]string `mapstructure:"labels"`
+}
+
+/**
+* 读取targets.groups中的标签规则并检查glob格式
+ * @return []TargetLabelRule
+ * @return error
+*/
+func LoadTargetLabelRules() (rules []TargetLabelRule, _err error) {
+	if _err = viper.UnmarshalKey("targets.groups", &rules); _err != nil {
+		return nil, _err
+	}
+	for _, rule := range rules {
+		if _, _err = path.Match(rule.Zone, ""); _err != nil {
+			return nil, fmt.Errorf("targets.groups中zone格式错误 %s: %v", rule.Zone, _err)
+		}
+		if _, _err = path.Match(rule.Host, ""); _err != nil {
+			return nil, fmt.Errorf("targets.groups中host格式错误 %s: %v", rule.Host, _err)
+		}
+	}
+	return rules, nil
+}
+
+/**
+* 判断规则是否匹配解析记录
+ * @param record
+ * @return bool
+*/
+func (r TargetLabelRule) Match(record DomainRecord) bool {
+	if r.Provider != "" && r.Provider != record.Provider {
+		return false
+	}
+	if r.Zone != "" {
+		if matched, _ := path.Match(strings.ToLower(r.Zone), strings.ToLower(record.Zone)); !matched {
+			return false
+		}
+	}
+	if r.Host != "" {
+		if matched, _ := path.Match(strings.ToLower(r.Host), strings.ToLower(record.Host())); !matched {
+			return false
+		}
+	}
+	return true
+}
+
+/**
+* 计算目标的标签：默认标签 < 匹配的targets.groups规则(后面的优先) < 清单中自带的标签
+ * @param target
+ * @param rules
+ * @return map[string]string
+*/
+func targetLabels(target string, rules []TargetLabelRule) map[string]string {
+	labels := make(map[string]string)
+	defaults := viper.GetStringMapString("targets.labels")
+	if !viper.IsSet("targets.labels") {
+		defaults = defaultTargetLabels
+	}
+	for key, value := range defaults {
+		labels[key] = value
+	}
+
+	i, ok := recordIndex[target]
+	if !ok {
+		return labels
+	}
+	record := recordSlice[i]
+	for _, rule := range rules {
+		if rule.Match(record) {
+			for key, value := range rule.Labels {
+				labels[key] = value
+			}
+		}
+	}
+	for key, value := range record.Labels {
+		labels[key] = value
+	}
+	return labels
+}
+
+/**
+* 生成blackbox-exporter的file_sd目标，标签相同的目标放在同一组，分组和组内目标都排序
+ * @param targets
+ * @param rules
+ * @return []TargetGroup
+*/
+func buildTargetGroups(targets []string, rules []TargetLabelRule) []TargetGroup {
+	sort.Strings(targets)
+	scheme := viper.GetString("targets.scheme")
+
+	// 按标签分组，groupKeys用于分组排序
+	var groupKeys []string
+	groups := make(map[string]*TargetGroup)
+	for _, target := range targets {
+		labels := targetLabels(target, rules)
+		groupKey := formatLabels(labels)
+		if _, ok := groups[groupKey]; !ok {
+			groupKeys = append(groupKeys, groupKey)
+			groups[groupKey] = &TargetGroup{Labels: labels}
+		}
+		groups[groupKey].Targets = append(groups[groupKey].Targets, scheme+target)
+	}
+
+	sort.Strings(groupKeys)
+	targetGroups := make([]TargetGroup, 0, len(groupKeys))
+	for _, groupKey := range groupKeys {
+		targetGroups = append(targetGroups, *groups[groupKey])
+	}
+	return targetGroups
+}
+
+/**
+* 把file_sd目标写入文件，format为json或yaml，为空时按文件扩展名判断
+ * @param file
+ * @param format
+ * @param groups
+ * @return error
+*/
+func WriteFileSD(file string, format string, groups []TargetGroup) (_err error) {
+	if format == "" {
+		format = "yaml"
+		if strings.ToLower(filepath.Ext(file)) == ".json" {
+			format = "json"
+		}
+	}
+
+	var content []byte
+	switch strings.ToLower(format) {
+	case "json":
+		content, _err = json.MarshalIndent(groups, "", "  ")
+		content = append(content, '\n')
+	case "yaml", "yml":
+		content, _err = yaml.Marshal(groups)
+	default:
+		return fmt.Errorf("targets.format不支持%s，可选json、yaml", format)
+	}
+	if _err != nil {
+		return _err
+	}
+
+	return writeFileAtomic(file, content)
+}
+
+/**
+* 读取已有的file_sd文件，JSON是YAML的子集，两种格式都按YAML解析
+ * @param file
+ * @return []TargetGroup
+ * @return error
+*/
+func ReadFileSD(file string) (groups []TargetGroup, _err error) {
+	content, _err := ioutil.ReadFile(file)
+	if _err != nil {
+		return nil, _err
+	}
+	if _err = yaml.Unmarshal(content, &groups); _err != nil {
+		return nil, fmt.Errorf("解析%s异常: %v", file, _err)
+	}
+	return groups, nil
+}
+
+/**
+* 先写临时文件再重命名，避免Prometheus读到写了一半的文件
+ * @param file
+ * @param content
+ * @return error
+*/
+func writeFileAtomic(file string, content []byte) (_err error) {
+	// 临时文件和目标文件放在同一个目录下，保证rename是原子操作
+	tmpFile, _err := ioutil.TempFile(filepath.Dir(file), "."+filepath.Base(file)+".tmp")
+	if _err != nil {
+		return _err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, _err = tmpFile.Write(content); _err != nil {
+		tmpFile.Close()
+		return _err
+	}
+	if _err = tmpFile.Chmod(0644); _err != nil {
+		tmpFile.Close()
+		return _err
+	}
+	if _err = tmpFile.Close(); _err != nil {
+		return _err
+	}
+	return os.Rename(tmpFile.Name(), file)
+}
@@ -0,0 +1,150 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+/**
+* 标签相同的目标放在同一组：默认标签 < targets.groups规则(后面的优先) < 清单中自带的标签
+ * @param t
+*/
+func TestBuildTargetGroups(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(func() {
+		viper.Reset()
+		recordSlice = nil
+		recordIndex = make(map[string]int)
+	})
+	viper.Set("targets.scheme", "https://")
+	viper.Set("targets.labels", map[string]string{"group": "web", "department": "ops"})
+
+	inventory := newDomainInventory()
+	inventory.add(DomainRecord{Provider: "aliyun", Zone: "example.com", RR: "www", Type: "A", Value: "192.0.2.1"})
+	inventory.add(DomainRecord{Provider: "aliyun", Zone: "example.com", RR: "api", Type: "A", Value: "192.0.2.2"})
+	inventory.add(DomainRecord{Provider: "tencent", Zone: "example.cn", RR: "www", Type: "CNAME", Value: "lb.example.net"})
+	inventory.add(DomainRecord{Provider: "static", RR: "oa.example.com", Port: 8443, Labels: map[string]string{"group": "it"}})
+	recordSlice, recordIndex = inventory.records, inventory.index
+
+	rules := []TargetLabelRule{
+		{Zone: "example.com", Labels: map[string]string{"group": "official-site"}},
+		{Provider: "aliyun", Host: "api.*", Labels: map[string]string{"group": "api", "team": "backend"}},
+	}
+	targets := []string{"www.example.com", "api.example.com", "www.example.cn", "oa.example.com:8443", "unknown.example.org"}
+	got := buildTargetGroups(targets, rules)
+	want := []TargetGroup{
+		{Targets: []string{"https://api.example.com"}, Labels: map[string]string{"group": "api", "department": "ops", "team": "backend"}},
+		{Targets: []string{"https://oa.example.com:8443"}, Labels: map[string]string{"group": "it", "department": "ops"}},
+		{Targets: []string{"https://www.example.com"}, Labels: map[string]string{"group": "official-site", "department": "ops"}},
+		{Targets: []string{"https://unknown.example.org", "https://www.example.cn"}, Labels: map[string]string{"group": "web", "department": "ops"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("buildTargetGroups = %+v, want %+v", got, want)
+	}
+}
+
+/**
+* 按配置的格式或文件扩展名写入file_sd文件，读回来与写入的目标一致，不留下临时文件
+ * @param t
+*/
+func TestWriteFileSD(t *testing.T) {
+	groups := []TargetGroup{
+		{Targets: []string{"https://api.example.com", "https://www.example.com:8443"}, Labels: map[string]string{"group": "web", "team": "backend"}},
+		{Targets: []string{"https://oa.example.com"}},
+	}
+
+	tests := []struct {
+		file   string
+		format string
+		// 按对应格式解析文件内容
+		unmarshal func([]byte, interface{}) error
+	}{
+		{"targets.yml", "yaml", yaml.Unmarshal},
+		{"targets.yml", "", yaml.Unmarshal},
+		{"targets.json", "json", json.Unmarshal},
+		{"targets.json", "", json.Unmarshal},
+		{"targets.txt", "JSON", json.Unmarshal},
+	}
+	for _, test := range tests {
+		dir := t.TempDir()
+		file := filepath.Join(dir, test.file)
+		// 已有的文件直接被替换
+		if err := ioutil.WriteFile(file, []byte("old"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := WriteFileSD(file, test.format, groups); err != nil {
+			t.Fatalf("%s(%s): %v", test.file, test.format, err)
+		}
+
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var parsed []TargetGroup
+		if err := test.unmarshal(content, &parsed); err != nil {
+			t.Fatalf("%s(%s)格式错误: %v\n%s", test.file, test.format, err, content)
+		}
+		if !reflect.DeepEqual(parsed, groups) {
+			t.Errorf("%s(%s)内容 = %+v, want %+v", test.file, test.format, parsed, groups)
+		}
+		if read, err := ReadFileSD(file); err != nil || !reflect.DeepEqual(read, groups) {
+			t.Errorf("ReadFileSD(%s) = %+v, %v", test.file, read, err)
+		}
+		// 没有标签的组不输出labels
+		if strings.Contains(string(content), "null") {
+			t.Errorf("%s(%s)包含null:\n%s", test.file, test.format, content)
+		}
+		assertOnlyFile(t, dir, test.file)
+	}
+}
+
+/**
+* 不支持的格式返回错误，已有的文件保持不变
+ * @param t
+*/
+func TestWriteFileSDUnsupportedFormat(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "targets.yml")
+	if err := ioutil.WriteFile(file, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFileSD(file, "toml", nil); err == nil {
+		t.Fatal("不支持的格式没有返回错误")
+	}
+	if content, _ := ioutil.ReadFile(file); string(content) != "old" {
+		t.Fatalf("文件内容 = %q, want old", content)
+	}
+	assertOnlyFile(t, dir, "targets.yml")
+}
+
+/**
+* 检查目录下只有指定的文件
+ * @param t
+ * @param dir
+ * @param name
+*/
+func assertOnlyFile(t *testing.T, dir string, name string) {
+	t.Helper()
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if len(names) != 1 || names[0] != name {
+		t.Fatalf("目录下的文件 = %v, want [%s]", names, name)
+	}
+}
@@ -0,0 +1,214 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// 定义一条过滤规则，glob和regex二选一，匹配的是主机记录(RR)，glob不区分大小写，regex按配置原样匹配(需要不区分大小写时加(?i))
+type FilterRule struct {
+	Glob  string `mapstructure:"glob"`
+	Regex string `mapstructure:"regex"`
+	re    *regexp.Regexp
+}
+
+// 定义按服务商/域名覆盖的过滤配置，provider和zone为空表示不限制，zone支持glob
+// types、status、include、exclude配置了就替换全局配置，没有配置则沿用全局配置
+type FilterOverride struct {
+	Provider string       `mapstructure:"provider"`
+	Zone     string       `mapstructure:"zone"`
+	Types    []string     `mapstructure:"types"`
+	Status   []string     `mapstructure:"status"`
+	Include  []FilterRule `mapstructure:"include"`
+	Exclude  []FilterRule `mapstructure:"exclude"`
+}
+
+// 定义解析记录过滤配置，对应config.yml中的filter
+type RecordFilter struct {
+	Types     []string         `mapstructure:"types"`
+	Status    []string         `mapstructure:"status"`
+	Include   []FilterRule     `mapstructure:"include"`
+	Exclude   []FilterRule     `mapstructure:"exclude"`
+	Overrides []FilterOverride `mapstructure:"overrides"`
+}
+
+// 没有配置filter(或其中某一项)时的默认规则，与原来写死的test/dev排除逻辑一致，主域名(@)默认也需要检查
+// 域名不区分大小写，默认的排除规则带上(?i)，大写的TEST/DEV记录同样排除
+var defaultRecordFilter = RecordFilter{
+	Types:  []string{"A", "AAAA", "CNAME"},
+	Status: []string{"ENABLE"},
+	Exclude: []FilterRule{
+		{Regex: `(?i)^(test|dev)$`},
+		{Regex: `(?i)^(test|dev)[.-]`},
+		{Regex: `(?i)[.-](test|dev)-`},
+		{Regex: `(?i)-(test|dev)\.`},
+		{Regex: `(?i)-(test|dev)$`},
+	},
+}
+
+/**
+* 读取config.yml中的filter配置并编译规则
+ * @return *RecordFilter
+ * @return error
+*/
+func LoadRecordFilter() (filter *RecordFilter, _err error) {
+	filter = &RecordFilter{}
+	if _err = viper.UnmarshalKey("filter", filter); _err != nil {
+		return nil, _err
+	}
+
+	// 没有配置exclude时使用默认的test/dev排除规则，配置为空列表(exclude: [])表示不排除
+	if !viper.IsSet("filter.exclude") {
+		filter.Exclude = append([]FilterRule(nil), defaultRecordFilter.Exclude...)
+	}
+	// 没有配置类型和状态时使用默认值
+	if len(filter.Types) == 0 {
+		filter.Types = defaultRecordFilter.Types
+	}
+	if len(filter.Status) == 0 {
+		filter.Status = defaultRecordFilter.Status
+	}
+
+	if _err = compileRules(filter.Include); _err != nil {
+		return nil, _err
+	}
+	if _err = compileRules(filter.Exclude); _err != nil {
+		return nil, _err
+	}
+	for _, override := range filter.Overrides {
+		if _, _err = path.Match(override.Zone, ""); _err != nil {
+			return nil, fmt.Errorf("filter.overrides中zone格式错误 %s: %v", override.Zone, _err)
+		}
+		if _err = compileRules(override.Include); _err != nil {
+			return nil, _err
+		}
+		if _err = compileRules(override.Exclude); _err != nil {
+			return nil, _err
+		}
+	}
+	return filter, nil
+}
+
+/**
+* 编译规则中的正则表达式并检查glob格式
+ * @param rules
+ * @return error
+*/
+func compileRules(rules []FilterRule) (_err error) {
+	for i := range rules {
+		rule := &rules[i]
+		if (rule.Glob == "") == (rule.Regex == "") {
+			return fmt.Errorf("过滤规则glob和regex必须且只能配置一个: %+v", *rule)
+		}
+		if rule.Regex != "" {
+			rule.re, _err = regexp.Compile(rule.Regex)
+			if _err != nil {
+				return fmt.Errorf("过滤规则regex格式错误 %s: %v", rule.Regex, _err)
+			}
+			continue
+		}
+		if _, _err = path.Match(rule.Glob, ""); _err != nil {
+			return fmt.Errorf("过滤规则glob格式错误 %s: %v", rule.Glob, _err)
+		}
+	}
+	return nil
+}
+
+/**
+* 判断规则是否匹配主机记录
+ * @param rr
+ * @return bool
+*/
+func (r FilterRule) Match(rr string) bool {
+	if r.re != nil {
+		return r.re.MatchString(rr)
+	}
+	matched, _ := path.Match(strings.ToLower(r.Glob), strings.ToLower(rr))
+	return matched
+}
+
+/**
+* 判断解析记录是否需要检查HTTPS
+ * @param record
+ * @return bool
+*/
+func (f *RecordFilter) Match(record DomainRecord) bool {
+	// 静态清单是人工维护的，全部需要检查
+	if record.Type == staticRecordType {
+		return true
+	}
+
+	types, status, include, exclude := f.Types, f.Status, f.Include, f.Exclude
+
+	// 后面的覆盖配置优先级更高
+	for _, override := range f.Overrides {
+		if override.Provider != "" && override.Provider != record.Provider {
+			continue
+		}
+		if override.Zone != "" {
+			if matched, _ := path.Match(strings.ToLower(override.Zone), strings.ToLower(record.Zone)); !matched {
+				continue
+			}
+		}
+		if override.Types != nil {
+			types = override.Types
+		}
+		if override.Status != nil {
+			status = override.Status
+		}
+		if override.Include != nil {
+			include = override.Include
+		}
+		if override.Exclude != nil {
+			exclude = override.Exclude
+		}
+	}
+
+	if !containsFold(types, record.Type) || !containsFold(status, record.Status) {
+		return false
+	}
+
+	// 配置了include时必须至少匹配一条
+	if len(include) > 0 && !matchAnyRule(include, record.RR) {
+		return false
+	}
+	return !matchAnyRule(exclude, record.RR)
+}
+
+/**
+* 是否匹配任意一条规则
+ * @param rules
+ * @param rr
+ * @return bool
+*/
+func matchAnyRule(rules []FilterRule, rr string) bool {
+	for _, rule := range rules {
+		if rule.Match(rr) {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+* 切片中是否包含某个字符串(不区分大小写)
+ * @param items
+ * @param s
+ * @return bool
+*/
+func containsFold(items []string, s string) bool {
+	for _, item := range items {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// 覆盖原来写死的test/dev排除逻辑各个分支的主机记录
+var filterTestRRs = []string{
+	"@", "www", "api", "test", "dev", "testing", "devops", "latest", "contest",
+	"test.api", "dev.api", "test-api", "dev-api", "api.test-v1", "api.dev-v1",
+	"api-test-v1", "api-dev-v1", "api-test.v1", "api-dev.v1", "api-test", "api-dev",
+	"api.test", "api.dev", "mytest-api", "test_api", "pre-test2", "a.b.dev-c",
+}
+
+/**
+* 原来写死在服务商代码中的test/dev排除逻辑，true表示需要检查
+ * @param rr
+ * @return bool
+*/
+func legacyRecordFilter(rr string) bool {
+	return rr != "test" && rr != "dev" &&
+		!strings.Contains(rr, ".test-") &&
+		!strings.Contains(rr, ".dev-") &&
+		!strings.Contains(rr, "-test-") &&
+		!strings.Contains(rr, "-dev-") &&
+		!strings.Contains(rr, "-test.") &&
+		!strings.Contains(rr, "-dev.") &&
+		!strings.HasSuffix(rr, "-test") &&
+		!strings.HasSuffix(rr, "-dev") &&
+		!strings.HasPrefix(rr, "test.") &&
+		!strings.HasPrefix(rr, "test-") &&
+		!strings.HasPrefix(rr, "dev.") &&
+		!strings.HasPrefix(rr, "dev-")
+}
+
+/**
+* 按yaml内容重新加载filter配置
+ * @param t
+ * @param content
+ * @return *RecordFilter
+*/
+func loadTestFilter(t *testing.T, content string) *RecordFilter {
+	viper.Reset()
+	viper.SetConfigType("yaml")
+	if err := viper.ReadConfig(strings.NewReader(content)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(viper.Reset)
+
+	filter, err := LoadRecordFilter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filter
+}
+
+/**
+* 默认规则与原来写死的排除逻辑一致，只配置filter.types时也要保留默认的排除规则
+ * @param t
+*/
+func TestRecordFilterDefaultEquivalence(t *testing.T) {
+	configs := map[string]string{
+		"没有配置filter":      "other: 1\n",
+		"只配置filter.types": "filter:\n  types: [\"A\", \"CNAME\"]\n",
+	}
+	for name, content := range configs {
+		filter := loadTestFilter(t, content)
+		for _, rr := range filterTestRRs {
+			record := DomainRecord{Provider: "aliyun", Zone: "example.com", RR: rr, Type: "A", Status: "ENABLE"}
+			if got, want := filter.Match(record), legacyRecordFilter(rr); got != want {
+				t.Errorf("%s: Match(%q) = %v, want %v", name, rr, got, want)
+			}
+		}
+	}
+}
+
+/**
+* 显式配置exclude: []时不排除任何记录，类型和状态仍然生效
+ * @param t
+*/
+func TestRecordFilterEmptyExclude(t *testing.T) {
+	filter := loadTestFilter(t, "filter:\n  exclude: []\n")
+	if len(filter.Exclude) != 0 {
+		t.Fatalf("Exclude = %+v, want []", filter.Exclude)
+	}
+	if !filter.Match(DomainRecord{RR: "test", Type: "A", Status: "ENABLE"}) {
+		t.Error("exclude: []时test记录应该检查")
+	}
+	if filter.Match(DomainRecord{RR: "www", Type: "MX", Status: "ENABLE"}) {
+		t.Error("MX记录不应该检查")
+	}
+	if filter.Match(DomainRecord{RR: "www", Type: "A", Status: "DISABLE"}) {
+		t.Error("暂停的记录不应该检查")
+	}
+}
+
+/**
+* 用户配置的regex按原样匹配(区分大小写，需要时自己加(?i))，默认排除规则和glob不区分大小写
+ * @param t
+*/
+func TestRecordFilterRegexCase(t *testing.T) {
+	filter := loadTestFilter(t, "filter:\n  exclude:\n    - regex: \"^Staging$\"\n    - regex: \"(?i)^canary\"\n    - glob: \"tmp-*\"\n")
+	tests := []struct {
+		rr   string
+		want bool
+	}{
+		{"Staging", false},
+		{"staging", true},
+		{"CANARY-1", false},
+		{"TMP-1", false},
+		// 配置了exclude后不再使用默认规则
+		{"TEST", true},
+	}
+	for _, test := range tests {
+		if got := filter.Match(DomainRecord{RR: test.rr, Type: "A", Status: "ENABLE"}); got != test.want {
+			t.Errorf("Match(%q) = %v, want %v", test.rr, got, test.want)
+		}
+	}
+
+	filter = loadTestFilter(t, "other: 1\n")
+	for _, rr := range []string{"TEST", "Dev-Api", "api-TEST"} {
+		if filter.Match(DomainRecord{RR: rr, Type: "A", Status: "ENABLE"}) {
+			t.Errorf("默认规则Match(%q) = true, want false", rr)
+		}
+	}
+}
@@ -7,17 +7,10 @@ package main
 import (
 	"bufio"
 	"bytes"
-	"crypto/tls"
 	"encoding/json"
+	"flag"
 	"fmt"
-	alidns "github.com/alibabacloud-go/alidns-20150109/v2/client"
-	aliopenapi "github.com/alibabacloud-go/darabonba-openapi/client"
-	"github.com/alibabacloud-go/tea/tea"
 	"github.com/spf13/viper"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
-	dnspod "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/dnspod/v20210323"
 	"io/ioutil"
 	"log"
 	"net"
@@ -25,24 +18,31 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // 定义变量或初始化
 var (
-	aliyunDomainSlice  []string
-	tencentDomainSlice []string
-	recordSlice        []string
-	errlogFile         *os.File
-	errlogger          *log.Logger
-	infologFile        *os.File
-	infologger         *log.Logger
-	setpStatusMap      map[string][]string
-	httpsDomainSum     = 0
-	successText        = "执行完成"
-	failText           = "执行失败"
-	successColor       = "green"
-	failColor          = "red"
+	providerConfs    []ProviderConfig
+	domainSliceMap   = make(map[string][]string)
+	recordSlice      []DomainRecord
+	recordIndex      = make(map[string]int)
+	wildcardSlice    []string
+	probeIssueSlice  []string
+	expirySlice      []CertExpiry
+	chainReportSlice []ChainReport
+	probeResultSlice []ProbeResult
+	errlogFile       *os.File
+	errlogger        *log.Logger
+	infologFile      *os.File
+	infologger       *log.Logger
+	setpStatusMap    map[string][]string
+	httpsDomainSum   = 0
+	successText      = "执行完成"
+	failText         = "执行失败"
+	successColor     = "green"
+	failColor        = "red"
 )
 
 // 定义调用通知接口的入参结构体
@@ -87,16 +87,10 @@ func init() {
 	}
 
 	// 初始化setpStatusMap并设置默认值，只要后续流程没有重写这些值，那么相应阶段就是执行失败
+	// 各DNS服务商阶段的默认值在ClientInit读取providers配置后设置
 	setpStatusMap = make(map[string][]string)
-	setpStatusMap["aliyunInitStatus"] = []string{"执行失败", "red"}
-	setpStatusMap["aliyunDescribeDomainsStatus"] = []string{"执行失败", "red"}
-	setpStatusMap["aliyunDescribeDomainRecordsStatus"] = []string{"执行失败", "red"}
-	setpStatusMap["tencentInitStatus"] = []string{"执行失败", "red"}
-	setpStatusMap["tencentDescribeDomainsStatus"] = []string{"执行失败", "red"}
-	setpStatusMap["tencentDescribeDomainRecordsStatus"] = []string{"执行失败", "red"}
 	setpStatusMap["expirationHttpsDomainStatus"] = []string{"执行失败", "red"}
 	setpStatusMap["reloadPrometheusStatus"] = []string{"执行失败", "red"}
-	setpStatusMap["aliyunInitStatus"] = []string{"执行失败", "red"}
 }
 
 /**
@@ -129,366 +123,269 @@ func GetConfig() (_err error) {
 		return err
 	}
 
-	// 获取配置值
-	//aliyun_key := viper.GetString("cloud.alibaba.aliyun_key") // 读取字符串
+	// 配置文件中没有的配置项使用默认值
+	viper.SetDefault("expiry.warning_days", 30)
+	viper.SetDefault("expiry.critical_days", 7)
+	viper.SetDefault("probe.workers", 20)
+	viper.SetDefault("probe.per_ip_limit", 4)
+	viper.SetDefault("probe.rate_limit", 20)
+	viper.SetDefault("probe.progress_interval", 10)
+	viper.SetDefault("probe.retries", 2)
+	viper.SetDefault("probe.retry_backoff", 1000)
+	viper.SetDefault("daemon.run_on_start", true)
+	viper.SetDefault("daemon.shutdown_timeout", 300)
+	viper.SetDefault("metrics.path", "/metrics")
+	viper.SetDefault("http_sd.path", "/targets")
+	viper.SetDefault("rules.probe_failure_for", "5m")
+	viper.SetDefault("targets.file", "aliyun-tencent-httpsdomain.yml")
+	viper.SetDefault("targets.scheme", "https://")
+	viper.SetDefault("notify.email.port", 587)
+	viper.SetDefault("notify.email.security", EmailSecurityStartTLS)
+	viper.SetDefault("notify.email.subject", "HTTPS域名证书检查报告")
+	viper.SetDefault("notify.email.timeout", 30)
+	viper.SetDefault("notify.webhook_timeout", 10)
+	setWebhookTimeout(viper.GetInt("notify.webhook_timeout"))
+
+	// DNS服务商配置在LoadProviderConfigs中读取，没有providers时兼容旧版的cloud.alibaba和cloud.tencent配置
 	return nil
 }
 
 /**
-* 初始化阿里云SDK
- * @param accessKeyId
- * @param accessKeySecret
- * @return *alidns.Client
+* 根据配置初始化所有DNS服务商SDK
+ * @return []DNSProvider
  * @return error
 */
-func AliyunInit(accessKeyId *string, accessKeySecret *string, regionId *string) (alidnsClient *alidns.Client, _err error) {
+func ClientInit() (providers []DNSProvider, _err error) {
 
-	// &号表示创建了一个aliopenapi.Config类型的零值实例，并获取了这个实例的内存地址。这个地址被赋值给了config变量。因此config是一个指向aliopenapi.Config类型值的指针。
-	config := &aliopenapi.Config{}
-	config.AccessKeyId = accessKeyId
-	config.AccessKeySecret = accessKeySecret
-	config.RegionId = regionId
-
-	// _result是一个指向alidns.Client类型的指针
-	ailClient, _err := alidns.NewClient(config)
-	return ailClient, _err
-}
-
-/**
-* 初始化腾讯云SDK
- * @param secretId
- * @param secretKey
- * @return *dnspod.Client
- * @return error
-*/
-func TencentInit(secretId string, secretKey string) (txdnsClient *dnspod.Client, err error) {
-
-	credential := common.NewCredential(
-		secretId,
-		secretKey,
-	)
-
-	// 实例化一个client选项，可选的，没有特殊需求可以跳过
-	cpf := profile.NewClientProfile()
-	cpf.HttpProfile.Endpoint = "dnspod.tencentcloudapi.com"
-
-	// 实例化要请求产品的client对象,clientProfile是可选的
-	txClient, err := dnspod.NewClient(credential, "", cpf)
-	return txClient, err
-}
-
-/**
-* 初始化阿里云和腾讯云SDK
- * @return *alidns.Client
- * @return *dnspod.Client
- * @return error
-*/
-func ClientInit() (aliyunClient *alidns.Client, tencentClient *dnspod.Client, _err error) {
-
-	// 阿里云密钥
-	aliyunAccessKeyId := viper.GetString("cloud.alibaba.aliyun_key")
-	aliyunAccessKeySecret := viper.GetString("cloud.alibaba.aliyun_secret")
-	regionId := viper.GetString("cloud.alibaba.region")
-
-	// 腾讯云密钥
-	tencentSecretId := viper.GetString("cloud.tencent.tencent_key")
-	tencentSecretKey := viper.GetString("cloud.tencent.tencent_secret")
-
-	// 初始阿里云化客户端
-	ailClient, _err := AliyunInit(&aliyunAccessKeyId, &aliyunAccessKeySecret, &regionId)
+	// 读取服务商配置
+	providerConfs, _err = LoadProviderConfigs()
 	if _err != nil {
-		setpStatusMap["aliyunInitStatus"] = []string{failText, failColor}
-		errlogger.Printf("初始化阿里云SDK:执行失败: %v", _err)
-		return nil, nil, _err
-	} else {
-		setpStatusMap["aliyunInitStatus"] = []string{successText, successColor}
-		infologger.Printf("初始化阿里云SDK:执行完成")
+		errlogger.Printf("读取providers配置异常: %v", _err)
+		return nil, _err
 	}
 
-	// 初始腾讯云客户端
-	txClient, _err := TencentInit(tencentSecretId, tencentSecretKey)
-	if _err != nil {
-		setpStatusMap["tencentInitStatus"] = []string{failText, failColor}
-		errlogger.Printf("初始化腾讯云SDK:执行失败: %v", _err)
-		return nil, nil, _err
-	} else {
-		setpStatusMap["tencentInitStatus"] = []string{successText, successColor}
-		infologger.Printf("初始化腾讯云SDK:执行完成")
+	// 先把每个服务商的各阶段都设置为执行失败，只要后续流程没有重写这些值，那么相应阶段就是执行失败
+	for _, conf := range providerConfs {
+		setpStatusMap[conf.Name+"InitStatus"] = []string{failText, failColor}
+		setpStatusMap[conf.Name+"DescribeDomainsStatus"] = []string{failText, failColor}
+		setpStatusMap[conf.Name+"DescribeDomainRecordsStatus"] = []string{failText, failColor}
 	}
 
-	return ailClient, txClient, _err
+	// 按配置顺序初始化客户端
+	for _, conf := range providerConfs {
+		provider, _err := NewDNSProvider(conf)
+		if _err != nil {
+			setpStatusMap[conf.Name+"InitStatus"] = []string{failText, failColor}
+			errlogger.Printf("初始化%sSDK:执行失败: %v", conf.Title, _err)
+			return nil, _err
+		} else {
+			setpStatusMap[conf.Name+"InitStatus"] = []string{successText, successColor}
+			infologger.Printf("初始化%sSDK:执行完成", conf.Title)
+		}
+		providers = append(providers, provider)
+	}
+
+	return providers, nil
 }
 
 /**
-* 查询阿里云域名列表
- * @param *alidns.Client
+* 查询所有服务商的域名列表
+ * @param providers
  * @return error
 */
-func AliyunDescribeDomains(client *alidns.Client) (_err error) {
-	// 定义初始页码和每页大小
-	pageNumber := 1
-	pageSize := 20
-
-	// for循环主要是循环每页
-	for {
-		// 创建一个指向dns.DescribeDomainsRequest类型结构体的指针，并初始化其成员变量PageNumber和PageSize
-		// &alidns.DescribeDomainsRequest{}这部分创建了一个alidns.DescribeDomainsRequest类型的新实例，并且由于前面加了&，所以创建的是指向该实例的一个指针
-		req := &alidns.DescribeDomainsRequest{
-			PageNumber: tea.Int64(int64(pageNumber)),
-			PageSize:   tea.Int64(int64(pageSize)),
-		}
+func DescribeDomains(providers []DNSProvider) (_err error) {
+
+	for _, provider := range providers {
+		title := providerTitle(provider.Name())
 
-		resp, _err := client.DescribeDomains(req)
+		domains, _err := provider.DescribeDomains()
 		if _err != nil {
+			setpStatusMap[provider.Name()+"DescribeDomainsStatus"] = []string{failText, failColor}
+			errlogger.Printf("调用%s域名列表接口:执行失败: %v", title, _err)
 			return _err
-		}
-
-		if len(resp.Body.Domains.Domain) == 0 {
-			return nil
 		} else {
-			for _, domain := range resp.Body.Domains.Domain {
-				aliyunDomainSlice = append(aliyunDomainSlice, *domain.DomainName)
-			}
-			// 更新页码以获取下一页
-			pageNumber++
+			setpStatusMap[provider.Name()+"DescribeDomainsStatus"] = []string{successText, successColor}
+			infologger.Printf("调用%s域名列表接口:执行完成", title)
 		}
+		domainSliceMap[provider.Name()] = domains
 	}
 	return nil
 }
 
 /**
-* 查询腾讯云域名列表
- * @param *dnspod.Client
+* 查询所有服务商的域名解析记录，全部服务商都查询成功后才替换domains.txt和当前的域名清单，
+* 任何一个服务商失败都保留上一次的清单，避免探测阶段把缩水的清单发布给Prometheus
+ * @param providers
  * @return error
 */
-func TencentDescribeDomains(client *dnspod.Client) (_err error) {
+func DescribeDomainRecords(providers []DNSProvider) (_err error) {
 
-	// 不用分页，默认显示3000条
-	// 实例化一个请求对象,每个接口都会对应一个request对象
-	request := dnspod.NewDescribeDomainListRequest()
+	// 读取解析记录过滤规则
+	filter, _err := LoadRecordFilter()
+	if _err != nil {
+		errlogger.Printf("读取filter配置异常: %v", _err)
+		return _err
+	}
 
-	// 返回的resp是一个DescribeDomainListResponse的实例，与请求对象对应
-	response, err := client.DescribeDomainList(request)
-	if err != nil {
-		return err
+	// 读取归属规则，给域名加上team/env/business标签
+	ownerRules, _err = LoadOwnerRules()
+	if _err != nil {
+		errlogger.Printf("读取ownership配置异常: %v", _err)
+		return _err
 	}
 
-	if len(response.Response.DomainList) == 0 {
-		return nil
-	} else {
-		for _, domain := range response.Response.DomainList {
-			tencentDomainSlice = append(tencentDomainSlice, *domain.Name)
+	// 每次同步都重新生成域名清单
+	inventory := newDomainInventory()
+	for _, provider := range providers {
+		title := providerTitle(provider.Name())
+
+		_err = describeProviderRecords(provider, filter, inventory)
+		if _err != nil {
+			setpStatusMap[provider.Name()+"DescribeDomainRecordsStatus"] = []string{failText, failColor}
+			errlogger.Printf("调用%s域名解析接口:执行失败，保留上一次的域名清单: %v", title, _err)
+			return _err
+		} else {
+			setpStatusMap[provider.Name()+"DescribeDomainRecordsStatus"] = []string{successText, successColor}
+			infologger.Printf("调用%s域名解析接口:执行完成", title)
 		}
 	}
 
-	return err
+	// 先写临时文件再重命名，写入失败时domains.txt仍是上一次的清单
+	_err = writeFileAtomic("domains.txt", inventory.targets.Bytes())
+	if _err != nil {
+		errlogger.Printf("写入domain.txt文件异常: %v", _err)
+		return _err
+	}
+	recordSlice = inventory.records
+	recordIndex = inventory.index
+	wildcardSlice = inventory.wildcards
+	return nil
+}
+
+// 定义一次同步中生成的域名清单
+type domainInventory struct {
+	records   []DomainRecord
+	index     map[string]int
+	wildcards []string
+	// domains.txt的内容，每行一个目标
+	targets bytes.Buffer
 }
 
 /**
-* 查询腾讯云域名列表
- * @param *alidns.Client
- * @param *dnspod.Client
- * @return error
+* 创建空的域名清单
+ * @return *domainInventory
 */
-func DescribeDomains(aliyunClient *alidns.Client, tencentClient *dnspod.Client) (_err error) {
-
-	// 1.查询阿里云域名列表
-	_err = AliyunDescribeDomains(aliyunClient)
-	if _err != nil {
-		setpStatusMap["aliyunDescribeDomainsStatus"] = []string{failText, failColor}
-		errlogger.Printf("调用阿里云域名列表接口:执行失败: %v", _err)
-		panic(_err)
-		// return _err
-	} else {
-		setpStatusMap["aliyunDescribeDomainsStatus"] = []string{successText, successColor}
-		infologger.Printf("调用阿里云域名列表接口:执行完成")
-	}
-
-	//2.查询腾讯云域名列表
-	_err = TencentDescribeDomains(tencentClient)
-	if _err != nil {
-		setpStatusMap["tencentDescribeDomainsStatus"] = []string{failText, failColor}
-		errlogger.Printf("调用腾讯云域名列表接口:执行失败: %v", _err)
-		panic(_err)
-		// return _err
-	} else {
-		setpStatusMap["tencentDescribeDomainsStatus"] = []string{successText, successColor}
-		infologger.Printf("调用腾讯云域名列表接口:执行完成")
-	}
-	return _err
+func newDomainInventory() *domainInventory {
+	return &domainInventory{index: make(map[string]int)}
 }
 
 /**
-* 查询阿里云域名解析记录
- * @param *alidns.Client
- * @param domains
- * @param domainRecordFile
+* 查询单个服务商下所有域名的解析记录，符合过滤规则的加入域名清单
+ * @param provider
+ * @param filter
+ * @param inventory
  * @return error
 */
-func AliyunDescribeDomainRecords(client *alidns.Client, domains *[]string, domainRecordFile *os.File) (_err error) {
-
-	// 解引用指针获取实际的切片
-	domainNames := *domains
-
-	// 解引用指针获取实际的切片
-	for _, domainName := range domainNames {
-		// 定义初始页码和每页大小
-		pageNumber := 1
-		pageSize := 20
-
-	InnerLoop:
-		for {
-			// 创建一个指向alidns.DescribeDomainsRequest类型结构体的指针，并初始化其成员变量PageNumber和PageSize
-			// &alidns.DescribeDomainsRequest{}这部分创建了一个alidns.DescribeDomainsRequest类型的新实例，并且由于前面加了&，所以创建的是指向该实例的一个指针
-			req := &alidns.DescribeDomainRecordsRequest{
-				PageNumber: tea.Int64(int64(pageNumber)),
-				PageSize:   tea.Int64(int64(pageSize)),
-			}
-			req.DomainName = &domainName
+func describeProviderRecords(provider DNSProvider, filter *RecordFilter, inventory *domainInventory) (_err error) {
+	for _, domainName := range domainSliceMap[provider.Name()] {
+		records, _err := provider.DescribeDomainRecords(domainName)
+		if _err != nil {
+			return _err
+		}
 
-			resp, _err := client.DescribeDomainRecords(req)
-			if _err != nil {
-				return _err
+		for _, record := range records {
+			if !filter.Match(record) {
+				continue
 			}
-
-			if len(resp.Body.DomainRecords.Record) == 0 {
-				// 使用 break outerLoop 跳出内层循环，继续外层循环
-				break InnerLoop
-			} else {
-				for _, record := range resp.Body.DomainRecords.Record {
-					if (*record.Type == "A" || *record.Type == "CNAME") &&
-						*record.Status == "ENABLE" &&
-						*record.RR != "@" &&
-						*record.RR != "test" && *record.RR != "dev" &&
-						!strings.Contains(*record.RR, ".test-") &&
-						!strings.Contains(*record.RR, ".dev-") &&
-						!strings.Contains(*record.RR, "-test-") &&
-						!strings.Contains(*record.RR, "-dev-") &&
-						!strings.Contains(*record.RR, "-test.") &&
-						!strings.Contains(*record.RR, "-dev.") &&
-						!strings.HasSuffix(*record.RR, "-test") &&
-						!strings.HasSuffix(*record.RR, "-dev") &&
-						!strings.HasPrefix(*record.RR, "test.") &&
-						!strings.HasPrefix(*record.RR, "test-") &&
-						!strings.HasPrefix(*record.RR, "dev.") &&
-						!strings.HasPrefix(*record.RR, "dev-") {
-						recordSlice = append(recordSlice, *record.RR+"."+*record.DomainName)
-						// 将域名写入到文件中
-						_, _err = domainRecordFile.WriteString(*record.RR + "." + *record.DomainName + "\n")
-						if _err != nil {
-							errlogger.Printf("写入domain.txt文件异常: %v", _err)
-						}
-					}
+			// 泛解析记录按配置的策略处理
+			if strings.HasPrefix(record.RR, "*") {
+				var ok bool
+				if record, ok = inventory.resolveWildcard(record); !ok {
+					continue
 				}
-				// 更新页码以获取下一页
-				pageNumber++
 			}
+			inventory.add(applyOwnership(record))
 		}
 	}
 	return nil
 }
 
 /**
-* 查询腾讯云域名解析记录
- * @param *dnspod.Client
- * @param domains
- * @param domainRecordFile
- * @return error
+* 处理泛解析记录，strategy为probe时把*替换成配置的label后探测，为skip(默认)时跳过并记录到通知中
+ * @param record
+ * @return DomainRecord
+ * @return bool
 */
-func TencentDescribeDomainRecords(client *dnspod.Client, domains *[]string, domainRecordFile *os.File) (_err error) {
-
-	// 解引用指针获取实际的切片
-	domainNames := *domains
-
-	var sum int = 0
-	// 解引用指针获取实际的切片
-	for _, domainName := range domainNames {
-		req := dnspod.NewDescribeRecordListRequest()
-		var offset uint64 = 0
-		// 不用分页，默认显示3000条,最大的域名的所有记录578条
-		var limit uint64 = 3000
-		req.Offset = &offset
-		req.Limit = &limit
-		req.Domain = &domainName
-
-		response, err := client.DescribeRecordList(req)
-		if _, ok := err.(*errors.TencentCloudSDKError); ok {
-			errlogger.Printf("调用腾讯云API返回错误: %v", _err)
-			return
-		}
-		if err != nil {
-			return err
-		}
+func (inventory *domainInventory) resolveWildcard(record DomainRecord) (DomainRecord, bool) {
+	// 只有最左边一段是*才是合法的泛解析
+	if record.RR != "*" && !strings.HasPrefix(record.RR, "*.") {
+		errlogger.Printf("泛解析记录格式异常，跳过: %s", record.Host())
+		inventory.wildcards = append(inventory.wildcards, record.Host())
+		return record, false
+	}
 
-		for _, record := range response.Response.RecordList {
-			sum++
-			if (*record.Type == "A" || *record.Type == "CNAME") &&
-				*record.Status == "ENABLE" &&
-				*record.Name != "@" &&
-				*record.Name != "test" && *record.Name != "dev" &&
-				!strings.Contains(*record.Name, ".test-") &&
-				!strings.Contains(*record.Name, ".dev-") &&
-				!strings.Contains(*record.Name, "-test-") &&
-				!strings.Contains(*record.Name, "-dev-") &&
-				!strings.Contains(*record.Name, "-test.") &&
-				!strings.Contains(*record.Name, "-dev.") &&
-				!strings.HasSuffix(*record.Name, "-test") &&
-				!strings.HasSuffix(*record.Name, "-dev") &&
-				!strings.HasPrefix(*record.Name, "test.") &&
-				!strings.HasPrefix(*record.Name, "test-") &&
-				!strings.HasPrefix(*record.Name, "dev.") &&
-				!strings.HasPrefix(*record.Name, "dev-") {
-				recordSlice = append(recordSlice, *record.Name+"."+domainName)
-				// 将域名写入到文件中
-				_, _err = domainRecordFile.WriteString(*record.Name + "." + domainName + "\n")
-				if _err != nil {
-					errlogger.Printf("写入domain.txt文件异常: %v", _err)
-				}
-			}
-		}
+	label := viper.GetString("wildcard.label")
+	if viper.GetString("wildcard.strategy") != "probe" || label == "" {
+		infologger.Printf("跳过泛解析记录: %s", record.Host())
+		inventory.wildcards = append(inventory.wildcards, record.Host())
+		return record, false
 	}
-	return nil
+
+	record.RR = label + strings.TrimPrefix(record.RR, "*")
+	infologger.Printf("泛解析记录使用%s探测: %s", label, record.Host())
+	return record, true
 }
 
 /**
-* 查询域名解析记录
- * @param *alidns.Client
- * @param *dnspod.Client
- * @return error
+* 把解析记录加入域名清单，多个服务商或多条记录值对应同一个域名时只保留一条，并合并标签
+ * @param record
 */
-func DescribeDomainRecords(aliyunClient *alidns.Client, tencentClient *dnspod.Client) (_err error) {
+func (inventory *domainInventory) add(record DomainRecord) {
+	target := record.Target()
 
-	// 清空domain.txt 文件
-	domainRecordFile, _err := os.OpenFile("domains.txt", os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0644)
-	if _err != nil {
-		errlogger.Printf("打开、创建或者清理domain.txt文件异常: %v", _err)
-		return _err
+	// A/AAAA记录的值是IP，按域名汇总供逐IP探测使用
+	var ip string
+	if (record.Type == "A" || record.Type == "AAAA") && net.ParseIP(record.Value) != nil {
+		ip = record.Value
 	}
-	defer domainRecordFile.Close()
 
-	// 1.查询阿里云域名解析记录
-	_err = AliyunDescribeDomainRecords(aliyunClient, &aliyunDomainSlice, domainRecordFile)
-	if _err != nil {
-		setpStatusMap["aliyunDescribeDomainRecordsStatus"] = []string{failText, failColor}
-		errlogger.Printf("调用阿里云域名解析接口:执行失败: %v", _err)
-		return _err
-	} else {
-		setpStatusMap["aliyunDescribeDomainRecordsStatus"] = []string{successText, successColor}
-		infologger.Printf("调用阿里云域名解析接口:执行完成")
+	if i, ok := inventory.index[target]; ok {
+		existing := &inventory.records[i]
+		if ip != "" && !containsFold(existing.IPs, ip) {
+			existing.IPs = append(existing.IPs, ip)
+		}
+		// 已经存在的域名只补充缺少的标签，先加入清单的记录优先
+		for key, value := range record.Labels {
+			if existing.Labels == nil {
+				existing.Labels = make(map[string]string)
+			}
+			if _, exists := existing.Labels[key]; !exists {
+				existing.Labels[key] = value
+			}
+		}
+		return
 	}
 
-	// 2.查询腾讯云域名解析记录
-	_err = TencentDescribeDomainRecords(tencentClient, &tencentDomainSlice, domainRecordFile)
-	if _err != nil {
-		setpStatusMap["tencentDescribeDomainRecordsStatus"] = []string{failText, failColor}
-		errlogger.Printf("调用腾讯云域名解析接口:执行失败: %v", _err)
-		return _err
-	} else {
-		setpStatusMap["tencentDescribeDomainRecordsStatus"] = []string{successText, successColor}
-		infologger.Printf("调用腾讯云域名解析接口:执行完成")
+	if ip != "" {
+		record.IPs = []string{ip}
 	}
+	inventory.index[target] = len(inventory.records)
+	inventory.records = append(inventory.records, record)
+	inventory.targets.WriteString(target + "\n")
+}
 
-	return nil
+/**
+* 根据服务商名称获取通知中显示的名称
+ * @param name
+ * @return string
+*/
+func providerTitle(name string) string {
+	for _, conf := range providerConfs {
+		if conf.Name == name {
+			return conf.Title
+		}
+	}
+	return name
 }
 
 /**
@@ -505,13 +402,12 @@ func ExpirationHttpsDomain() (_err error) {
 	}
 	defer domainFile.Close()
 
-	// 清空template.yml文件，用于生成blackbox-exporter的配置文件（只部分片段）
-	templateFile, err := os.OpenFile("aliyun-tencent-httpsdomain.yml", os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0644)
+	// 读取file_sd目标的标签规则，探测完成后生成blackbox-exporter的targets文件
+	labelRules, err := LoadTargetLabelRules()
 	if err != nil {
-		errlogger.Printf("打开、创建获取清理httpsdomain.yml文件异常: %v", err)
-		return _err
+		errlogger.Printf("读取targets.groups配置异常: %v", err)
+		return err
 	}
-	defer templateFile.Close()
 
 	// 打开文件
 	file, err := os.Open("domains.txt")
@@ -524,78 +420,121 @@ func ExpirationHttpsDomain() (_err error) {
 	// 创建一个新的scanner来读取文件
 	scanner := bufio.NewScanner(file)
 
-	// 用于等待一组并发操作完成
+	// 用于等待所有探测协程完成
 	var wg sync.WaitGroup
-	// 用于保护对domainFile和templateString的并发访问
+	// 用于保护对domainFile和httpsTargets的并发访问
 	var mutex sync.Mutex
 
-	// 初始化模板字符串
-	var templateString strings.Builder
-	templateString.WriteString("- targets:\n") // 开始模板字符串
+	// 探测成功的目标，最后按标签分组写入模板文件
+	var httpsTargets []string
+	probeIssueSlice = nil
+	expirySlice = nil
+	chainReportSlice = nil
+	probeResultSlice = nil
+	httpsDomainSum = 0
 
-	// 匿名函数，用于并发，后面go processDomain(domain)调用
-	processDomain := func(domain string) {
-		// 匿名函数退出的时候执行，wg.Done()方法用于减少等待组的计数器。一个goroutine完成时，应调用wg.Done()来通知等待组告知完成。这有助于sync.WaitGroup能够正确地跟踪还有多少个goroutine正在运行，以及是否所有的goroutine都已经完成
-		defer wg.Done()
+	// 按配置限制全局握手速率和同一个IP的并发数
+	probeLimiter = NewProbeLimiter(viper.GetInt("probe.rate_limit"), viper.GetInt("probe.per_ip_limit"))
 
-		// 创建TCP连接探测443端口是否通，异常记录错误日志
-		conn, err := net.DialTimeout("tcp", domain+":443", 5*time.Second)
+	// 加载自定义CA，用于校验内网自签证书
+	probeRootCAs, err = LoadRootCAs(viper.GetString("probe.ca_file"))
+	if err != nil {
+		errlogger.Printf("加载probe.ca_file异常: %v", err)
+		return err
+	}
+
+	// 证书到期告警阈值
+	now := time.Now()
+	warningDays := viper.GetInt("expiry.warning_days")
+	criticalDays := viper.GetInt("expiry.critical_days")
+
+	// 匿名函数，探测单个目标，由后面的探测协程调用
+	processDomain := func(domain string) {
+		// 静态清单中的目标可以带端口，没有端口的默认443
+		host, port, err := net.SplitHostPort(domain)
 		if err != nil {
-			errlogger.Printf("连接异常 %s: %v", domain, err)
-			return
+			host, port = domain, "443"
 		}
-		defer conn.Close()
 
-		// 创建TLS配置并启动TLS握手，异常记录错误日志
-		tlsConfig := &tls.Config{
-			ServerName:         domain,
-			InsecureSkipVerify: false,
+		// 逐IP探测时对域名后面的每个地址分别握手；开启IPv6探测时IPv4和IPv6分别握手；否则与原来一样由系统选择地址族
+		var outcome probeOutcome
+		start := time.Now()
+		if viper.GetBool("probe.per_ip") {
+			outcome, err = probePerIP(host, port, targetIPs(domain), viper.GetBool("probe.ipv6"))
+		} else if viper.GetBool("probe.ipv6") {
+			outcome, err = probeDualStack(host, port)
+		} else {
+			outcome, err = probeDefault(host, port)
 		}
-		tlsConn := tls.Client(conn, tlsConfig)
-		err = tlsConn.Handshake()
-		if err != nil {
-			errlogger.Printf("TLS handshake异常 %s: %v", domain, err)
+		result := newProbeResult(domain, host, port, outcome, err, time.Since(start), start)
+		state, issues := outcome.State, outcome.Issues
+		if err != nil && len(state.PeerCertificates) == 0 {
+			errlogger.Printf("%s %s", domain, err)
+			mutex.Lock()
+			probeResultSlice = append(probeResultSlice, result)
+			mutex.Unlock()
 			return
 		}
-
-		// 获取连接状态并提取证书，异常记录错误日志
-		state := tlsConn.ConnectionState()
-		if len(state.PeerCertificates) == 0 {
-			errlogger.Printf("提取证书异常 %s", domain)
-			return
+		// 证书校验失败(过期、缺少中间证书、域名不匹配等)时仍然拿到了证书，继续检查到期时间和证书链，不再从监控中丢掉
+		if err != nil {
+			errlogger.Printf("%s %s", domain, err)
+		}
+		for _, issue := range issues {
+			errlogger.Printf("%s %s", domain, issue)
 		}
 
 		// 获取第一个证书（通常是叶子证书）
 		cert := state.PeerCertificates[0]
 
-		// 获取证书到期时间
+		// 获取证书到期时间并按阈值分级，多个地址证书不一致时取最早到期的
 		expiration := cert.NotAfter
+		if !outcome.NotAfter.IsZero() {
+			expiration = outcome.NotAfter
+		}
 		infologger.Printf("Certificate for %s expires on: %s\n", domain, expiration)
+		expiry := classifyExpiry(domain, expiration, now, warningDays, criticalDays)
+
+		// 分析证书链，记录每一张证书并对问题分类
+		chainReport := analyzeChain(domain, host, state.PeerCertificates, now)
+		for _, problem := range outcome.ChainProblems {
+			if !containsFold(chainReport.Problems, problem) {
+				chainReport.Problems = append(chainReport.Problems, problem)
+			}
+		}
+		result.DaysLeft = &expiry.DaysLeft
+		result.ExpiryLevel = expiry.Level
+		result.ChainProblems = chainReport.Problems
 
 		// 使用互斥锁来保护对文件的写入，将域名写入到文件中
 		mutex.Lock()
+		if expiry.Level != CertLevelOK {
+			expirySlice = append(expirySlice, expiry)
+		}
+		if len(chainReport.Problems) > 0 {
+			chainReportSlice = append(chainReportSlice, chainReport)
+		}
+		probeResultSlice = append(probeResultSlice, result)
+		for _, issue := range issues {
+			probeIssueSlice = append(probeIssueSlice, domain+": "+issue)
+		}
 		_, err = domainFile.WriteString(domain + "\n")
 		if err != nil {
 			errlogger.Printf("写入域名异常 %s to file: %v", domain, err)
 		}
 		// 累加https成功域名的数量
 		httpsDomainSum++
-
-		// 构造新的目标条目并追加到模板字符串
-		templateString.WriteString(fmt.Sprintf("   - https://%s\n", domain))
+		httpsTargets = append(httpsTargets, domain)
 		mutex.Unlock()
 	}
 
-	// 遍历每一行，多少行启动多少个线程，一般最好声明数量
+	// 先读取全部目标，用于统计进度
+	var domains []string
 	for scanner.Scan() {
 		domain := strings.TrimSpace(scanner.Text())
 		if domain == "" {
 			continue // 跳过空行
 		}
-
-		// 在启动一个新的goroutine之前调用它，以表示有一个新的goroutine将执行，并需要在之后的某个时刻等待其完成。
-		wg.Add(1)
-		go processDomain(domain)
+		domains = append(domains, domain)
 	}
 
 	// 检查读取过程中是否出错
@@ -603,53 +542,95 @@ func ExpirationHttpsDomain() (_err error) {
 		errlogger.Printf("读取文件异常: %v", err)
 	}
 
-	// 用于阻塞调用它的goroutine，直到等待组的计数器变为零。这通常意味着所有添加到等待组的goroutine都已经通过调用wg.Done()完成了它们的工作
+	// 按配置启动固定数量的探测协程，避免域名很多时一次性打开大量连接
+	workers := viper.GetInt("probe.workers")
+	if workers <= 0 {
+		workers = 1
+	}
+	infologger.Printf("开始探测HTTPS域名%d个，并发数%d", len(domains), workers)
+
+	var done int64
+	stopProgress := make(chan struct{})
+	go reportProgress(&done, len(domains), time.Duration(viper.GetInt("probe.progress_interval"))*time.Second, stopProgress)
+
+	domainChan := make(chan string)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for domain := range domainChan {
+				processDomain(domain)
+				atomic.AddInt64(&done, 1)
+			}
+		}()
+	}
+	for _, domain := range domains {
+		domainChan <- domain
+	}
+	close(domainChan)
+
+	// 用于阻塞调用它的goroutine，直到所有探测协程退出
 	wg.Wait()
+	close(stopProgress)
+
+	// 生成本次探测的JSON/CSV报表
+	writeReports(probeResultSlice)
+
+	// 更新/metrics中的证书指标
+	updateProbeMetrics(probeResultSlice)
 
-	// 添加 labels 部分
-	templateString.WriteString("  labels:\n")
-	templateString.WriteString("    group: web\n")
-	templateString.WriteString("    department: test-auto\n")
+	// 生成告警规则文件，与targets文件一起被后面的Reload加载
+	if err = WriteAlertRules(); err != nil {
+		errlogger.Printf("生成告警规则文件异常: %v", err)
+		return err
+	}
 
-	// 将模板字符串写入到文件中
-	_, err = templateFile.WriteString(templateString.String())
+	// 生成blackbox-exporter的file_sd文件，同时更新HTTP服务发现接口返回的目标
+	targetGroups := buildTargetGroups(httpsTargets, labelRules)
+	setTargetGroups(targetGroups)
+	targetsFile := viper.GetString("targets.file")
+	if targetsFile == "" {
+		return nil
+	}
+	err = WriteFileSD(targetsFile, viper.GetString("targets.format"), targetGroups)
 	if err != nil {
-		errlogger.Printf("写入template.yml文件异常: %v", err)
+		errlogger.Printf("写入%s文件异常: %v", targetsFile, err)
+		return err
 	}
 	return nil
 }
 
 /**
-* 调用Prometheus Reload接口
- * @return error
+* 获取探测目标在DNS服务商中登记的IP，probe.ip_source为records时使用，否则返回空由探测时解析
+ * @param target
+ * @return []string
 */
-func ReloadPrometheus() (_err error) {
-	// 要请求的URL
-	url := viper.GetString("api.prometheus_api")
-
-	// 创建一个空的POST请求
-	resp, _err := http.Post(url, "application/json", nil)
-	if _err != nil {
-		errlogger.Printf("请求异常: %v", _err)
-		return _err
+func targetIPs(target string) []string {
+	if viper.GetString("probe.ip_source") != "records" {
+		return nil
 	}
-	defer resp.Body.Close()
-
-	// 读取响应体
-	body, _err := ioutil.ReadAll(resp.Body)
-	if _err != nil {
-		errlogger.Printf("响应异常: %v", _err)
-		return _err
+	if i, ok := recordIndex[target]; ok {
+		return recordSlice[i].IPs
 	}
-
-	// 打印响应状态码和响应体
-	infologger.Printf("调用Prometheus Reload接口状态码: %s", resp.Status)
-	infologger.Printf("调用Prometheus Reload接口出参: %s", string(body))
 	return nil
 }
 
 /**
-* 执行结果发送通知
+* 获取某个阶段的执行状态，没有记录的阶段按执行失败处理
+ * @param setpStatusMap
+ * @param key
+ * @return []string
+*/
+func stepStatus(setpStatusMap map[string][]string, key string) []string {
+	status, ok := setpStatusMap[key]
+	if !ok {
+		return []string{failText, failColor}
+	}
+	return status
+}
+
+/**
+* 执行结果发送企业微信通知
  * @param httpsDomainSum
  * @param setpStatusMap
  * @return error
@@ -659,52 +640,94 @@ func NoticeWeCom(httpsDomainSum int, setpStatusMap map[string][]string) (_err er
 	// 企业微信Webhook URL
 	url := viper.GetString("api.wx_api")
 
-	// 准备Markdown消息内容
-	markdownMessage := func() string {
-		aliyunInitStatus := setpStatusMap["aliyunInitStatus"]
-		aliyunDescribeDomainsStatus := setpStatusMap["aliyunDescribeDomainsStatus"]
-		aliyunDescribeDomainRecordsStatus := setpStatusMap["aliyunDescribeDomainRecordsStatus"]
-		tencentInitStatus := setpStatusMap["tencentInitStatus"]
-		tencentDescribeDomainsStatus := setpStatusMap["tencentDescribeDomainsStatus"]
-		tencentDescribeDomainRecordsStatus := setpStatusMap["tencentDescribeDomainRecordsStatus"]
-		expirationHttpsDomainStatus := setpStatusMap["expirationHttpsDomainStatus"]
-		reloadPrometheusStatus := setpStatusMap["reloadPrometheusStatus"]
-
-		content := fmt.Sprintf(`本次已同步HTTPS域名<font color="yellow">%d条</font>，请相关同事注意。
-
-		> 【阿里云】
-		> 1、初始化阿里云SDK: <font color="%s">%s</font>
-		> 2、调用阿里云域名列表接口: <font color="%s">%s</font>
-		> 3、调用阿里云域名解析接口: <font color="%s">%s</font>
-
-		> 【腾讯云】
-		> 1、初始化腾讯云SDK: <font color="%s">%s</font>
-		> 2、调用腾讯云域名列表接口: <font color="%s">%s</font>
-		> 3、调用腾讯云域名解析接口: <font color="%s">%s</font>
-
-		> 【HTTPS域名检查】
-		> 4、检查HTTPS域名到期时间: <font color="%s">%s</font>
-		> 5、Reload状态: <font color="%s">%s</font>`,
-			httpsDomainSum,
-			aliyunInitStatus[1], aliyunInitStatus[0],
-			aliyunDescribeDomainsStatus[1], aliyunDescribeDomainsStatus[0],
-			aliyunDescribeDomainRecordsStatus[1], aliyunDescribeDomainRecordsStatus[0],
-			tencentInitStatus[1], tencentInitStatus[0],
-			tencentDescribeDomainsStatus[1], tencentDescribeDomainsStatus[0],
-			tencentDescribeDomainRecordsStatus[1], tencentDescribeDomainRecordsStatus[0],
-			expirationHttpsDomainStatus[1], expirationHttpsDomainStatus[0],
-			reloadPrometheusStatus[1], reloadPrometheusStatus[0])
-
-		return content
+	return sendWeComMarkdown(url, noticeMarkdown(httpsDomainSum, setpStatusMap))
+}
+
+/**
+* 生成通知的Markdown内容，颜色使用企业微信的写法，其它渠道按需转换
+ * @param httpsDomainSum
+ * @param setpStatusMap
+ * @return string
+*/
+func noticeMarkdown(httpsDomainSum int, setpStatusMap map[string][]string) string {
+	var content strings.Builder
+	content.WriteString(fmt.Sprintf(`本次已同步HTTPS域名<font color="yellow">%d条</font>，请相关同事注意。`, httpsDomainSum))
+
+	// 按配置顺序输出每个DNS服务商的执行状态，最后是HTTPS域名检查的执行状态
+	for _, section := range noticeSections(setpStatusMap) {
+		content.WriteString(fmt.Sprintf(`
+
+		> 【%s】`, section.Title))
+		for _, step := range section.Steps {
+			content.WriteString(fmt.Sprintf(`
+		> %s: <font color="%s">%s</font>`, step.Name, step.Status[1], step.Status[0]))
+		}
 	}
 
+	content.WriteString(noticeDetails())
+	return content.String()
+}
+
+/**
+* 生成通知中各阶段执行状态之后的明细：证书到期、证书链异常、多地址证书异常、跳过的泛解析记录
+ * @return string
+*/
+func noticeDetails() string {
+	var content strings.Builder
+
+	// 列出已过期和即将到期的证书
+	content.WriteString(expiryNoticeContent(expirySlice))
+
+	// 列出证书链有问题的域名
+	if len(chainReportSlice) > 0 {
+		content.WriteString(fmt.Sprintf(`
+
+		> 【证书链异常】<font color="red">%d条</font>`, len(chainReportSlice)))
+		for _, report := range chainReportSlice {
+			content.WriteString(fmt.Sprintf(`
+		> %s: %s`, report.Target, chainProblemsText(report.Problems)))
+		}
+	}
+
+	// 列出IPv6/逐IP探测发现证书不一致、过期或部分地址探测失败的域名
+	if len(probeIssueSlice) > 0 {
+		content.WriteString(fmt.Sprintf(`
+
+		> 【多地址证书异常】<font color="warning">%d条</font>`, len(probeIssueSlice)))
+		for _, issue := range probeIssueSlice {
+			content.WriteString(fmt.Sprintf(`
+		> %s`, issue))
+		}
+	}
+
+	// 列出跳过的泛解析记录，避免误以为已经检查
+	if len(wildcardSlice) > 0 {
+		content.WriteString(fmt.Sprintf(`
+
+		> 【跳过的泛解析记录】<font color="comment">%d条</font>`, len(wildcardSlice)))
+		for _, host := range wildcardSlice {
+			content.WriteString(fmt.Sprintf(`
+		> %s`, host))
+		}
+	}
+
+	return content.String()
+}
+
+/**
+* 发送企业微信Markdown消息
+ * @param url
+ * @param content
+ * @return error
+*/
+func sendWeComMarkdown(url string, content string) (_err error) {
 	// 准备Markdown消息内容
 	message := MarkdownMessage{
 		MsgType: "markdown",
 		Markdown: struct {
 			Content string `json:"content"`
 		}{
-			Content: markdownMessage(),
+			Content: content,
 		},
 	}
 
@@ -726,11 +749,8 @@ func NoticeWeCom(httpsDomainSum int, setpStatusMap map[string][]string) (_err er
 	// 设置请求头
 	req.Header.Set("Content-Type", "application/json")
 
-	// 创建一个HTTP客户端来发送请求
-	client := &http.Client{}
-
-	// 发送请求并获取响应
-	resp, _err := client.Do(req)
+	// 发送请求并获取响应，使用带超时的webhook客户端，避免接口无响应时一直占用执行锁
+	resp, _err := webhookClient.Do(req)
 	if _err != nil {
 		errlogger.Printf("请求异常: %v", _err)
 		return _err
@@ -758,14 +778,16 @@ func _main() (_err error) {
 
 	// 匿名函数：退出之前发送通知
 	defer func() (_err error) {
-		_err = NoticeWeCom(httpsDomainSum, setpStatusMap)
+		_err = Notice(httpsDomainSum, setpStatusMap)
 		if _err != nil {
 			errlogger.Printf("发送通知失败: %v", _err)
 			return _err
 		} else {
 			infologger.Printf("发送通知成功")
 		}
-		return nil
+
+		// 按团队发送证书到期提醒
+		return NoticeTeams()
 	}()
 
 	// 加载配置文件
@@ -777,8 +799,8 @@ func _main() (_err error) {
 		infologger.Printf("加载配置文件成功")
 	}
 
-	// 1、初始化阿里云和腾讯云SDK
-	aliyunClient, tencentClient, _err := ClientInit()
+	// 1、初始化各DNS服务商SDK
+	providers, _err := ClientInit()
 	if _err != nil {
 		setpStatusMap["initStatus"] = []string{failText, failColor}
 		errlogger.Printf("调用域名列表接口:执行失败: %v", _err)
@@ -788,8 +810,31 @@ func _main() (_err error) {
 		infologger.Printf("调用域名列表接口:执行完成")
 	}
 
+	// 2、3.同步域名清单
+	_err = SyncInventory(providers)
+	if _err != nil {
+		return _err
+	}
+
+	// 4、5.探测并Reload Prometheus
+	return ProbeHttpsDomains()
+}
+
+/**
+* 同步域名清单：查询域名列表和域名解析记录，生成domains.txt
+ * @param providers
+ * @return error
+*/
+func SyncInventory(providers []DNSProvider) (_err error) {
+
+	// 常驻进程中会多次执行，先把本阶段的状态重置为执行失败，避免沿用上一次的结果
+	for _, provider := range providers {
+		setpStatusMap[provider.Name()+"DescribeDomainsStatus"] = []string{failText, failColor}
+		setpStatusMap[provider.Name()+"DescribeDomainRecordsStatus"] = []string{failText, failColor}
+	}
+
 	// 2、查询域名列表
-	_err = DescribeDomains(aliyunClient, tencentClient)
+	_err = DescribeDomains(providers)
 	if _err != nil {
 		setpStatusMap["describeDomainsStatus"] = []string{failText, failColor}
 		errlogger.Printf("调用域名列表接口:执行失败: %v", _err)
@@ -800,7 +845,7 @@ func _main() (_err error) {
 	}
 
 	// 3.查询域名解析记录
-	_err = DescribeDomainRecords(aliyunClient, tencentClient)
+	_err = DescribeDomainRecords(providers)
 	if _err != nil {
 		setpStatusMap["describeDomainRecordsStatus"] = []string{failText, failColor}
 		errlogger.Printf("调用域名解析接口:执行失败: %v", _err)
@@ -809,6 +854,18 @@ func _main() (_err error) {
 		setpStatusMap["describeDomainRecordsStatus"] = []string{successText, successColor}
 		infologger.Printf("调用域名解析接口:执行完成")
 	}
+	return nil
+}
+
+/**
+* 探测domains.txt中的HTTPS域名，生成blackbox-exporter的targets并Reload Prometheus
+ * @return error
+*/
+func ProbeHttpsDomains() (_err error) {
+
+	// 常驻进程中会多次执行，先把本阶段的状态重置为执行失败，避免沿用上一次的结果
+	setpStatusMap["expirationHttpsDomainStatus"] = []string{failText, failColor}
+	setpStatusMap["reloadPrometheusStatus"] = []string{failText, failColor}
 
 	// 4.检查https域名到期时间
 	// 让程序暂停 3 秒
@@ -843,8 +900,17 @@ func main() {
 	// 关闭日志文件
 	defer preClose()
 
-	// 由于_main先执行，它返回错误就会中断程序
-	err := _main()
+	// -daemon以常驻进程方式运行，按config.yml中daemon.schedules定时执行各阶段
+	daemon := flag.Bool("daemon", false, "以常驻进程方式运行，按daemon.schedules定时执行")
+	flag.Parse()
+
+	var err error
+	if *daemon {
+		err = runDaemon()
+	} else {
+		// 由于_main先执行，它返回错误就会中断程序
+		err = _main()
+	}
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,185 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// 测试用DNS服务商，err不为空时查询解析记录返回该错误
+type fakeDNSProvider struct {
+	name    string
+	records map[string][]DomainRecord
+	err     error
+}
+
+func (p *fakeDNSProvider) Name() string {
+	return p.name
+}
+
+func (p *fakeDNSProvider) DescribeDomains() ([]string, error) {
+	var domains []string
+	for domain := range p.records {
+		domains = append(domains, domain)
+	}
+	return domains, nil
+}
+
+func (p *fakeDNSProvider) DescribeDomainRecords(domain string) ([]DomainRecord, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.records[domain], nil
+}
+
+/**
+* 任何一个服务商查询解析记录失败时，保留上一次的domains.txt和域名清单
+ * @param t
+*/
+func TestSyncInventoryKeepsPreviousOnFailure(t *testing.T) {
+	workDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.Chdir(workDir)
+		viper.Reset()
+		recordSlice = nil
+		recordIndex = make(map[string]int)
+		wildcardSlice = nil
+	})
+
+	first := &fakeDNSProvider{name: "first", records: map[string][]DomainRecord{
+		"example.com": {
+			{Provider: "first", Zone: "example.com", RR: "www", Type: "A", Value: "192.0.2.1", Status: "ENABLE"},
+			{Provider: "first", Zone: "example.com", RR: "api", Type: "A", Value: "192.0.2.2", Status: "ENABLE"},
+		},
+	}}
+	second := &fakeDNSProvider{name: "second", records: map[string][]DomainRecord{
+		"example.org": {{Provider: "second", Zone: "example.org", RR: "www", Type: "CNAME", Value: "lb.example.net", Status: "ENABLE"}},
+	}}
+	providers := []DNSProvider{first, second}
+
+	if err := SyncInventory(providers); err != nil {
+		t.Fatal(err)
+	}
+	want := "www.example.com\napi.example.com\nwww.example.org\n"
+	if content, _ := ioutil.ReadFile("domains.txt"); string(content) != want {
+		t.Fatalf("domains.txt = %q, want %q", content, want)
+	}
+
+	// 第二个服务商限流，本次同步失败
+	second.err = errors.New("RequestLimitExceeded")
+	if err := SyncInventory(providers); err == nil {
+		t.Fatal("SyncInventory没有返回服务商的错误")
+	}
+	if content, _ := ioutil.ReadFile("domains.txt"); string(content) != want {
+		t.Fatalf("同步失败后domains.txt = %q, want %q", content, want)
+	}
+	if len(recordSlice) != 3 || recordSlice[recordIndex["www.example.org"]].Provider != "second" {
+		t.Fatalf("同步失败后域名清单 = %+v", recordSlice)
+	}
+	if status := setpStatusMap["secondDescribeDomainRecordsStatus"]; status[0] != failText {
+		t.Fatalf("secondDescribeDomainRecordsStatus = %v", status)
+	}
+
+	// 恢复后用新的清单替换
+	second.err = nil
+	delete(first.records, "example.com")
+	if err := SyncInventory(providers); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := ioutil.ReadFile("domains.txt"); string(content) != "www.example.org\n" {
+		t.Fatalf("domains.txt = %q, want www.example.org", content)
+	}
+	if len(recordSlice) != 1 {
+		t.Fatalf("域名清单 = %+v, want 1条", recordSlice)
+	}
+}
+
+/**
+* 主域名(@)按zone本身探测，泛解析记录skip时跳过并列出，probe时把*替换成label探测
+ * @param t
+*/
+func TestSyncInventoryApexAndWildcard(t *testing.T) {
+	workDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.Chdir(workDir)
+		viper.Reset()
+		recordSlice = nil
+		recordIndex = make(map[string]int)
+		wildcardSlice = nil
+	})
+
+	provider := &fakeDNSProvider{name: "aliyun", records: map[string][]DomainRecord{
+		"example.com": {
+			{Provider: "aliyun", Zone: "example.com", RR: "@", Type: "A", Value: "192.0.2.1", Status: "ENABLE"},
+			{Provider: "aliyun", Zone: "example.com", RR: "*", Type: "A", Value: "192.0.2.2", Status: "ENABLE"},
+			{Provider: "aliyun", Zone: "example.com", RR: "*.api", Type: "CNAME", Value: "lb.example.net", Status: "ENABLE"},
+			// *后面不是.不是合法的泛解析，任何策略都跳过
+			{Provider: "aliyun", Zone: "example.com", RR: "*web", Type: "A", Value: "192.0.2.3", Status: "ENABLE"},
+		},
+	}}
+	providers := []DNSProvider{provider}
+
+	tests := []struct {
+		strategy  string
+		domains   string
+		wildcards []string
+	}{
+		{
+			strategy:  "skip",
+			domains:   "example.com\n",
+			wildcards: []string{"*.example.com", "*.api.example.com", "*web.example.com"},
+		},
+		{
+			strategy:  "probe",
+			domains:   "example.com\nwildcard-probe.example.com\nwildcard-probe.api.example.com\n",
+			wildcards: []string{"*web.example.com"},
+		},
+	}
+	for _, test := range tests {
+		viper.Reset()
+		viper.Set("wildcard.strategy", test.strategy)
+		viper.Set("wildcard.label", "wildcard-probe")
+		if err := SyncInventory(providers); err != nil {
+			t.Fatal(err)
+		}
+		if content, _ := ioutil.ReadFile("domains.txt"); string(content) != test.domains {
+			t.Errorf("%s: domains.txt = %q, want %q", test.strategy, content, test.domains)
+		}
+		if !reflect.DeepEqual(wildcardSlice, test.wildcards) {
+			t.Errorf("%s: wildcardSlice = %q, want %q", test.strategy, wildcardSlice, test.wildcards)
+		}
+		if apex := recordSlice[recordIndex["example.com"]]; apex.RR != "@" || apex.Value != "192.0.2.1" {
+			t.Errorf("%s: 主域名记录 = %+v", test.strategy, apex)
+		}
+	}
+
+	// probe策略没有配置label时按skip处理
+	viper.Reset()
+	viper.Set("wildcard.strategy", "probe")
+	if err := SyncInventory(providers); err != nil {
+		t.Fatal(err)
+	}
+	if len(wildcardSlice) != 3 {
+		t.Fatalf("没有label时wildcardSlice = %q, want 3条", wildcardSlice)
+	}
+}
@@ -0,0 +1,165 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// 证书指标的标签，host为探测目标(非443端口时带端口)，team/env/business来自归属规则，与targets文件中的标签一致
+var certMetricLabels = []string{"host", "provider", "zone", OwnerLabelTeam, OwnerLabelEnv, OwnerLabelBusiness}
+
+// 定义/metrics暴露的指标，证书指标每次探测后全部重新生成，执行状态指标每个阶段执行完后更新
+var (
+	metricsRegistry = prometheus.NewRegistry()
+
+	certNotAfterGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ssl_cert_not_after",
+		Help: "叶子证书到期时间(Unix时间戳，秒)",
+	}, certMetricLabels)
+	certDaysLeftGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ssl_cert_days_remaining",
+		Help: "叶子证书剩余天数，已过期为负数",
+	}, certMetricLabels)
+	probeSuccessGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ssl_probe_success",
+		Help: "探测是否成功(握手成功且证书校验通过为1)",
+	}, certMetricLabels)
+	chainValidGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ssl_cert_chain_valid",
+		Help: "证书链是否正常(域名匹配、没有过期、中间证书完整、根证书受信任为1)",
+	}, certMetricLabels)
+	tlsVersionGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ssl_tls_version_info",
+		Help: "握手协商的TLS版本，值固定为1",
+	}, append(append([]string(nil), certMetricLabels...), "version"))
+
+	stepSuccessGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "httpsdomain_step_success",
+		Help: "各阶段最近一次的执行状态(与通知中的状态一致)，执行完成为1",
+	}, []string{"step"})
+	stageDurationGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "httpsdomain_stage_duration_seconds",
+		Help: "常驻进程中各阶段最近一次的执行耗时(秒)",
+	}, []string{"stage"})
+	stageLastRunGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "httpsdomain_stage_last_run_timestamp_seconds",
+		Help: "常驻进程中各阶段最近一次执行结束的时间(Unix时间戳，秒)",
+	}, []string{"stage"})
+	domainCountGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "httpsdomain_domains",
+		Help: "最近一次执行的域名数量，kind为inventory(域名清单)、https(探测成功)、expiring(即将到期/已过期)、chain_problem(证书链异常)、wildcard_skipped(跳过的泛解析)",
+	}, []string{"kind"})
+)
+
+// 上一次探测后各证书指标的标签值，下一次探测后只删除已经不存在的序列
+// 不使用Reset，避免抓取刚好发生在Reset和重新赋值之间时指标短暂消失
+var (
+	probeMetricsMutex sync.Mutex
+	probeMetricSeries = make(map[*prometheus.GaugeVec]map[string][]string)
+)
+
+/**
+* init函数，注册指标
+ */
+func init() {
+	metricsRegistry.MustRegister(
+		certNotAfterGauge,
+		certDaysLeftGauge,
+		probeSuccessGauge,
+		chainValidGauge,
+		tlsVersionGauge,
+		stepSuccessGauge,
+		stageDurationGauge,
+		stageLastRunGauge,
+		domainCountGauge,
+	)
+}
+
+/**
+* 根据本次探测结果更新证书指标，先写入本次的值再删除已经不在清单中的目标(或不再有证书、TLS版本变化)的序列
+ * @param results
+*/
+func updateProbeMetrics(results []ProbeResult) {
+	probeMetricsMutex.Lock()
+	defer probeMetricsMutex.Unlock()
+
+	series := make(map[*prometheus.GaugeVec]map[string][]string)
+	set := func(gauge *prometheus.GaugeVec, value float64, labels ...string) {
+		if series[gauge] == nil {
+			series[gauge] = make(map[string][]string)
+		}
+		series[gauge][strings.Join(labels, "\x00")] = labels
+		gauge.WithLabelValues(labels...).Set(value)
+	}
+
+	for _, result := range results {
+		labels := []string{result.Target, result.Provider, result.Zone,
+			result.Labels[OwnerLabelTeam], result.Labels[OwnerLabelEnv], result.Labels[OwnerLabelBusiness]}
+		if result.Error == "" {
+			set(probeSuccessGauge, 1, labels...)
+		} else {
+			set(probeSuccessGauge, 0, labels...)
+		}
+		if result.NotAfter != nil {
+			set(certNotAfterGauge, float64(result.NotAfter.Unix()), labels...)
+			if len(result.ChainProblems) == 0 {
+				set(chainValidGauge, 1, labels...)
+			} else {
+				set(chainValidGauge, 0, labels...)
+			}
+		}
+		if result.DaysLeft != nil {
+			set(certDaysLeftGauge, float64(*result.DaysLeft), labels...)
+		}
+		if result.TLSVersion != "" {
+			set(tlsVersionGauge, 1, append(labels, result.TLSVersion)...)
+		}
+	}
+
+	// 删除上一次有、本次没有的序列
+	for gauge, previous := range probeMetricSeries {
+		for key, labels := range previous {
+			if _, ok := series[gauge][key]; !ok {
+				gauge.DeleteLabelValues(labels...)
+			}
+		}
+	}
+	probeMetricSeries = series
+}
+
+/**
+* 根据setpStatusMap和本次执行结果更新执行状态指标
+ * @param setpStatusMap
+*/
+func updateStepMetrics(setpStatusMap map[string][]string) {
+	for step, status := range setpStatusMap {
+		if len(status) > 0 && status[0] == successText {
+			stepSuccessGauge.WithLabelValues(step).Set(1)
+		} else {
+			stepSuccessGauge.WithLabelValues(step).Set(0)
+		}
+	}
+
+	domainCountGauge.WithLabelValues("inventory").Set(float64(len(recordSlice)))
+	domainCountGauge.WithLabelValues("https").Set(float64(httpsDomainSum))
+	domainCountGauge.WithLabelValues("expiring").Set(float64(len(expirySlice)))
+	domainCountGauge.WithLabelValues("chain_problem").Set(float64(len(chainReportSlice)))
+	domainCountGauge.WithLabelValues("wildcard_skipped").Set(float64(len(wildcardSlice)))
+}
+
+/**
+* 记录常驻进程中一个阶段的执行耗时
+ * @param stage
+ * @param duration
+*/
+func observeStage(stage string, duration time.Duration) {
+	stageDurationGauge.WithLabelValues(stage).Set(duration.Seconds())
+	stageLastRunGauge.WithLabelValues(stage).Set(float64(time.Now().Unix()))
+}
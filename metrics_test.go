@@ -0,0 +1,88 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+/**
+* 统计指标在registry中的序列数量
+ * @param t
+ * @param name
+ * @return int
+*/
+func metricSeriesCount(t *testing.T, name string) int {
+	families, err := metricsRegistry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() == name {
+			return len(family.GetMetric())
+		}
+	}
+	return 0
+}
+
+/**
+* 目标从清单中移除后删除对应序列，更新期间抓取不能看到指标消失
+ * @param t
+*/
+func TestUpdateProbeMetrics(t *testing.T) {
+	notAfter := time.Now().Add(90 * 24 * time.Hour)
+	daysLeft := 90
+	result := func(target string, tlsVersion string) ProbeResult {
+		return ProbeResult{Target: target, Provider: "aliyun", Zone: "example.com", NotAfter: &notAfter, DaysLeft: &daysLeft, TLSVersion: tlsVersion}
+	}
+
+	updateProbeMetrics([]ProbeResult{result("a.example.com", "TLS 1.2"), result("b.example.com", "TLS 1.3")})
+	if got := metricSeriesCount(t, "ssl_probe_success"); got != 2 {
+		t.Fatalf("ssl_probe_success有%d个序列, want 2", got)
+	}
+
+	// b下线、a的TLS版本变化后，旧序列都要删除
+	updateProbeMetrics([]ProbeResult{result("a.example.com", "TLS 1.3")})
+	for name, want := range map[string]int{
+		"ssl_probe_success":       1,
+		"ssl_cert_not_after":      1,
+		"ssl_cert_days_remaining": 1,
+		"ssl_cert_chain_valid":    1,
+		"ssl_tls_version_info":    1,
+	} {
+		if got := metricSeriesCount(t, name); got != want {
+			t.Errorf("%s有%d个序列, want %d", name, got, want)
+		}
+	}
+
+	// 反复更新同一批目标时，并发抓取始终能看到该序列
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			updateProbeMetrics([]ProbeResult{result("a.example.com", "TLS 1.3")})
+		}
+		close(stop)
+	}()
+	for {
+		select {
+		case <-stop:
+			wg.Wait()
+			updateProbeMetrics(nil)
+			if got := metricSeriesCount(t, "ssl_probe_success"); got != 0 {
+				t.Fatalf("清单为空时ssl_probe_success有%d个序列, want 0", got)
+			}
+			return
+		default:
+			if got := metricSeriesCount(t, "ssl_probe_success"); got != 1 {
+				t.Fatalf("更新期间ssl_probe_success有%d个序列, want 1", got)
+			}
+		}
+	}
+}
@@ -0,0 +1,202 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// webhook请求的默认超时时间
+const defaultWebhookTimeout = 10 * time.Second
+
+// 企业微信、钉钉、飞书webhook共用的HTTP客户端，常驻进程中通知阶段持有执行锁，接口无响应时不能一直等待
+var webhookClient = &http.Client{Timeout: defaultWebhookTimeout}
+
+// 定义通知中的一组执行状态，例如一个DNS服务商的各阶段
+type NoticeSection struct {
+	Title string
+	Steps []NoticeStep
+}
+
+// 定义通知中一个阶段的执行状态，Status与setpStatusMap中的值一致(文字和颜色)
+type NoticeStep struct {
+	Name   string
+	Status []string
+}
+
+// 定义通知渠道需要实现的方法，NotifyTeam用于发送团队的证书到期提醒
+type Notifier interface {
+	Name() string
+	Notify(httpsDomainSum int, setpStatusMap map[string][]string) error
+	NotifyTeam(team string, expiry []CertExpiry) error
+}
+
+// 定义创建通知渠道的函数类型，配置从viper中读取
+type notifierFactory func() (Notifier, error)
+
+// 已支持的通知渠道，key为notify.channels中的名称
+var notifierFactories = map[string]notifierFactory{
+	"wecom":    NewWeComNotifier,
+	"dingtalk": NewDingTalkNotifier,
+	"feishu":   NewFeishuNotifier,
+	"email":    NewEmailNotifier,
+}
+
+// 定义按归属规则创建团队通知渠道的函数类型，webhook和secret来自规则
+type teamNotifierFactory func(rule OwnerRule) Notifier
+
+// 团队通知支持的渠道，key为ownership中的channel
+var teamNotifierFactories = map[string]teamNotifierFactory{
+	"wecom": func(rule OwnerRule) Notifier {
+		return &WeComNotifier{webhook: rule.Webhook}
+	},
+	"dingtalk": func(rule OwnerRule) Notifier {
+		return &DingTalkNotifier{webhook: rule.Webhook, secret: rule.Secret}
+	},
+	"feishu": func(rule OwnerRule) Notifier {
+		return &FeishuNotifier{webhook: rule.Webhook, secret: rule.Secret}
+	},
+}
+
+// 定义企业微信通知渠道，webhook用于团队通知，汇总通知使用api.wx_api
+type WeComNotifier struct {
+	webhook string
+}
+
+/**
+* 创建企业微信通知渠道
+ * @return Notifier
+ * @return error
+*/
+func NewWeComNotifier() (Notifier, error) {
+	return &WeComNotifier{}, nil
+}
+
+/**
+* 渠道名称
+ * @return string
+*/
+func (n *WeComNotifier) Name() string {
+	return "wecom"
+}
+
+/**
+* 发送企业微信通知
+ * @param httpsDomainSum
+ * @param setpStatusMap
+ * @return error
+*/
+func (n *WeComNotifier) Notify(httpsDomainSum int, setpStatusMap map[string][]string) error {
+	return NoticeWeCom(httpsDomainSum, setpStatusMap)
+}
+
+/**
+* 发送企业微信团队证书到期提醒
+ * @param team
+ * @param expiry
+ * @return error
+*/
+func (n *WeComNotifier) NotifyTeam(team string, expiry []CertExpiry) error {
+	return sendWeComMarkdown(n.webhook, teamNoticeMarkdown(team, expiry))
+}
+
+/**
+* 设置webhook请求的超时时间(秒)，小于等于0时使用默认值
+ * @param seconds
+*/
+func setWebhookTimeout(seconds int) {
+	if seconds <= 0 {
+		webhookClient.Timeout = defaultWebhookTimeout
+		return
+	}
+	webhookClient.Timeout = time.Duration(seconds) * time.Second
+}
+
+/**
+* 按配置顺序生成每个DNS服务商的执行状态，最后是HTTPS域名检查的执行状态
+ * @param setpStatusMap
+ * @return []NoticeSection
+*/
+func noticeSections(setpStatusMap map[string][]string) []NoticeSection {
+	var sections []NoticeSection
+	for _, conf := range providerConfs {
+		sections = append(sections, NoticeSection{
+			Title: conf.Title,
+			Steps: []NoticeStep{
+				{Name: fmt.Sprintf("1、初始化%sSDK", conf.Title), Status: stepStatus(setpStatusMap, conf.Name+"InitStatus")},
+				{Name: fmt.Sprintf("2、调用%s域名列表接口", conf.Title), Status: stepStatus(setpStatusMap, conf.Name+"DescribeDomainsStatus")},
+				{Name: fmt.Sprintf("3、调用%s域名解析接口", conf.Title), Status: stepStatus(setpStatusMap, conf.Name+"DescribeDomainRecordsStatus")},
+			},
+		})
+	}
+
+	sections = append(sections, NoticeSection{
+		Title: "HTTPS域名检查",
+		Steps: []NoticeStep{
+			{Name: "4、检查HTTPS域名到期时间", Status: stepStatus(setpStatusMap, "expirationHttpsDomainStatus")},
+			{Name: "5、Reload状态", Status: stepStatus(setpStatusMap, "reloadPrometheusStatus")},
+		},
+	})
+	return sections
+}
+
+/**
+* 是否有阶段执行失败
+ * @param sections
+ * @return bool
+*/
+func hasFailedStep(sections []NoticeSection) bool {
+	for _, section := range sections {
+		for _, step := range section.Steps {
+			if step.Status[0] != successText {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+/**
+* 按notify.channels配置依次发送通知，没有配置时只发送企业微信，某个渠道失败不影响其它渠道
+ * @param httpsDomainSum
+ * @param setpStatusMap
+ * @return error
+*/
+func Notice(httpsDomainSum int, setpStatusMap map[string][]string) (_err error) {
+	channels := viper.GetStringSlice("notify.channels")
+	if len(channels) == 0 {
+		channels = []string{"wecom"}
+	}
+
+	var failed []string
+	for _, channel := range channels {
+		factory, ok := notifierFactories[strings.ToLower(channel)]
+		if !ok {
+			errlogger.Printf("不支持的通知渠道: %s", channel)
+			failed = append(failed, channel+": 不支持的通知渠道")
+			continue
+		}
+
+		notifier, err := factory()
+		if err == nil {
+			err = notifier.Notify(httpsDomainSum, setpStatusMap)
+		}
+		if err != nil {
+			errlogger.Printf("发送%s通知失败: %v", channel, err)
+			failed = append(failed, fmt.Sprintf("%s: %v", channel, err))
+			continue
+		}
+		infologger.Printf("发送%s通知成功", channel)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d/%d个通知渠道发送失败: %s", len(failed), len(channels), strings.Join(failed, "; "))
+	}
+	return nil
+}
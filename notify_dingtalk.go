@@ -0,0 +1,206 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// 通知内容中企业微信的颜色写法转换成钉钉支持的十六进制颜色
+var dingTalkColors = map[string]string{
+	"red":     "#FF0000",
+	"green":   "#008000",
+	"yellow":  "#FFA500",
+	"warning": "#FFA500",
+	"info":    "#008000",
+	"comment": "#808080",
+}
+
+var fontColorRegexp = regexp.MustCompile(`<font color="(\w+)">`)
+
+// 定义钉钉群机器人通知渠道
+type DingTalkNotifier struct {
+	webhook   string
+	secret    string
+	atMobiles []string
+	atAll     bool
+}
+
+// 定义钉钉机器人Markdown消息
+type dingTalkMessage struct {
+	MsgType  string `json:"msgtype"`
+	Markdown struct {
+		Title string `json:"title"`
+		Text  string `json:"text"`
+	} `json:"markdown"`
+	At struct {
+		AtMobiles []string `json:"atMobiles,omitempty"`
+		IsAtAll   bool     `json:"isAtAll"`
+	} `json:"at"`
+}
+
+// 定义钉钉机器人接口出参
+type dingTalkResponse struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+/**
+* 根据notify.dingtalk配置创建钉钉通知渠道
+ * @return Notifier
+ * @return error
+*/
+func NewDingTalkNotifier() (Notifier, error) {
+	webhook := viper.GetString("notify.dingtalk.webhook")
+	if webhook == "" {
+		return nil, errors.New("没有配置notify.dingtalk.webhook")
+	}
+	return &DingTalkNotifier{
+		webhook:   webhook,
+		secret:    viper.GetString("notify.dingtalk.secret"),
+		atMobiles: viper.GetStringSlice("notify.dingtalk.at_mobiles"),
+		atAll:     viper.GetBool("notify.dingtalk.at_all"),
+	}, nil
+}
+
+/**
+* 渠道名称
+ * @return string
+*/
+func (n *DingTalkNotifier) Name() string {
+	return "dingtalk"
+}
+
+/**
+* 发送钉钉通知
+ * @param httpsDomainSum
+ * @param setpStatusMap
+ * @return error
+*/
+func (n *DingTalkNotifier) Notify(httpsDomainSum int, setpStatusMap map[string][]string) error {
+	return n.Send("HTTPS域名同步通知", dingTalkMarkdown(noticeMarkdown(httpsDomainSum, setpStatusMap)))
+}
+
+/**
+* 发送钉钉团队证书到期提醒
+ * @param team
+ * @param expiry
+ * @return error
+*/
+func (n *DingTalkNotifier) NotifyTeam(team string, expiry []CertExpiry) error {
+	return n.Send(fmt.Sprintf("【%s】证书到期提醒", team), dingTalkMarkdown(teamNoticeMarkdown(team, expiry)))
+}
+
+/**
+* 发送钉钉Markdown消息，配置了secret时对webhook加签，需要@的手机号追加到正文末尾
+ * @param title
+ * @param text
+ * @return error
+*/
+func (n *DingTalkNotifier) Send(title string, text string) (_err error) {
+	message := dingTalkMessage{MsgType: "markdown"}
+	message.Markdown.Title = title
+	message.At.AtMobiles = n.atMobiles
+	message.At.IsAtAll = n.atAll
+
+	// 钉钉要求正文中包含@手机号才会提醒对应的人
+	var mentions []string
+	for _, mobile := range n.atMobiles {
+		mentions = append(mentions, "@"+mobile)
+	}
+	if len(mentions) > 0 {
+		text += "\n\n" + strings.Join(mentions, " ")
+	}
+	message.Markdown.Text = text
+
+	messageBytes, _err := json.Marshal(message)
+	if _err != nil {
+		return _err
+	}
+
+	webhook := n.webhook
+	if n.secret != "" {
+		webhook = signDingTalkWebhook(webhook, n.secret, time.Now())
+	}
+	resp, _err := webhookClient.Post(webhook, "application/json", bytes.NewBuffer(messageBytes))
+	if _err != nil {
+		return _err
+	}
+	defer resp.Body.Close()
+
+	body, _err := ioutil.ReadAll(resp.Body)
+	if _err != nil {
+		return _err
+	}
+	infologger.Printf("调用钉钉接口状态码: %s", resp.Status)
+	infologger.Printf("调用钉钉接口出参: %s", string(body))
+
+	// 钉钉接口出错时状态码也是200，需要检查errcode
+	var result dingTalkResponse
+	if _err = json.Unmarshal(body, &result); _err != nil {
+		return fmt.Errorf("钉钉接口返回%s: %s", resp.Status, string(body))
+	}
+	if result.ErrCode != 0 {
+		return fmt.Errorf("钉钉接口返回错误%d: %s", result.ErrCode, result.ErrMsg)
+	}
+	return nil
+}
+
+/**
+* 钉钉机器人加签：timestamp+"\n"+secret做HmacSHA256，Base64后URL编码，与timestamp一起追加到webhook
+ * @param webhook
+ * @param secret
+ * @param now
+ * @return string
+*/
+func signDingTalkWebhook(webhook string, secret string, now time.Time) string {
+	timestamp := strconv.FormatInt(now.UnixNano()/int64(time.Millisecond), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+	sign := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	separator := "&"
+	if !strings.Contains(webhook, "?") {
+		separator = "?"
+	}
+	return webhook + separator + "timestamp=" + timestamp + "&sign=" + url.QueryEscape(sign)
+}
+
+/**
+* 企业微信格式的Markdown转换成钉钉格式：颜色转成十六进制，去掉行首缩进，每行单独成段(钉钉不识别单个换行)
+ * @param content
+ * @return string
+*/
+func dingTalkMarkdown(content string) string {
+	content = fontColorRegexp.ReplaceAllStringFunc(content, func(match string) string {
+		name := fontColorRegexp.FindStringSubmatch(match)[1]
+		if color, ok := dingTalkColors[name]; ok {
+			return `<font color="` + color + `">`
+		}
+		return match
+	})
+
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n\n")
+}
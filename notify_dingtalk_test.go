@@ -0,0 +1,80 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+/**
+* 钉钉加签的已知向量，期望值按钉钉文档的算法独立计算
+ * @param t
+*/
+func TestSignDingTalkWebhook(t *testing.T) {
+	now := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	got := signDingTalkWebhook("https://oapi.dingtalk.com/robot/send?access_token=abc", "SECexample", now)
+	want := "https://oapi.dingtalk.com/robot/send?access_token=abc&timestamp=1792137600000&sign=43CDcfRY4cJpjgGiTVQAnpj%2BJL1LW4Gpv%2FzK%2F50dlFM%3D"
+	if got != want {
+		t.Fatalf("signDingTalkWebhook = %s, want %s", got, want)
+	}
+
+	// webhook没有查询参数时使用?连接
+	if got := signDingTalkWebhook("http://127.0.0.1/robot", "SECexample", now); !strings.HasPrefix(got, "http://127.0.0.1/robot?timestamp=1792137600000&sign=") {
+		t.Fatalf("signDingTalkWebhook = %s", got)
+	}
+}
+
+/**
+* 发送时带上签名和@手机号，errcode不为0时返回错误
+ * @param t
+*/
+func TestDingTalkNotifierSend(t *testing.T) {
+	var message dingTalkMessage
+	response := `{"errcode":0,"errmsg":"ok"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("timestamp") == "" || r.URL.Query().Get("sign") == "" {
+			t.Errorf("请求没有签名: %s", r.URL)
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &message)
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	notifier := &DingTalkNotifier{webhook: server.URL + "/robot/send?access_token=abc", secret: "SECexample", atMobiles: []string{"13800000000"}}
+	if err := notifier.Send("HTTPS域名同步通知", `> 5、Reload状态: <font color="red">执行失败</font>`); err != nil {
+		t.Fatal(err)
+	}
+	if message.MsgType != "markdown" || !strings.HasSuffix(message.Markdown.Text, "\n\n@13800000000") {
+		t.Fatalf("消息内容错误: %+v", message)
+	}
+	if len(message.At.AtMobiles) != 1 || message.At.AtMobiles[0] != "13800000000" {
+		t.Fatalf("at.atMobiles错误: %+v", message.At)
+	}
+
+	response = `{"errcode":310000,"errmsg":"sign not match"}`
+	if err := notifier.Send("HTTPS域名同步通知", "test"); err == nil || err.Error() != "钉钉接口返回错误310000: sign not match" {
+		t.Fatalf("Send错误 = %v", err)
+	}
+}
+
+/**
+* 企业微信颜色转换成钉钉支持的十六进制颜色，每行单独成段
+ * @param t
+*/
+func TestDingTalkMarkdown(t *testing.T) {
+	got := dingTalkMarkdown("本次已同步<font color=\"yellow\">3条</font>\n\n\t\t> 【HTTPS域名检查】\n\t\t> 5、Reload状态: <font color=\"red\">执行失败</font>")
+	for _, want := range []string{`<font color="#FFA500">3条</font>`, `<font color="#FF0000">执行失败</font>`, "\n\n> 5、Reload状态"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("dingTalkMarkdown结果中没有%q: %s", want, got)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// 邮件连接的加密方式
+const (
+	EmailSecurityStartTLS = "starttls"
+	EmailSecurityTLS      = "tls"
+	EmailSecurityNone     = "none"
+)
+
+// 邮件正文模板，Sections为空时(团队邮件)不输出执行状态
+var emailTemplate = template.Must(template.New("email").Funcs(template.FuncMap{
+	"color":      emailColor,
+	"levelText":  certLevelText,
+	"levelColor": certLevelColor,
+	"date":       func(t time.Time) string { return t.Format("2006-01-02") },
+	"team":       targetTeam,
+	"problems":   chainProblemsText,
+}).Parse(`<html>
+<body style="font-family:Arial,'Microsoft YaHei',sans-serif;font-size:14px;color:#333333;">
+<p>{{.Summary}}</p>
+{{range .Sections}}
+<h3>{{.Title}}</h3>
+<table border="1" cellspacing="0" cellpadding="6" style="border-collapse:collapse;">
+<tr style="background:#F2F2F2;"><th>阶段</th><th>状态</th></tr>
+{{range .Steps}}<tr><td>{{.Name}}</td><td style="color:{{color (index .Status 1)}};">{{index .Status 0}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .Expiry}}
+<h3>证书到期提醒({{len .Expiry}}条)</h3>
+<table border="1" cellspacing="0" cellpadding="6" style="border-collapse:collapse;">
+<tr style="background:#F2F2F2;"><th>状态</th><th>域名</th><th>到期时间</th><th>剩余天数</th><th>团队</th></tr>
+{{range .Expiry}}<tr><td style="color:{{levelColor .Level}};">{{levelText .Level}}</td><td>{{.Target}}</td><td>{{date .NotAfter}}</td><td>{{.DaysLeft}}</td><td>{{team .Target}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .ChainReports}}
+<h3>证书链异常({{len .ChainReports}}条)</h3>
+<table border="1" cellspacing="0" cellpadding="6" style="border-collapse:collapse;">
+<tr style="background:#F2F2F2;"><th>域名</th><th>问题</th></tr>
+{{range .ChainReports}}<tr><td>{{.Target}}</td><td>{{problems .Problems}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .ProbeIssues}}
+<h3>多地址证书异常({{len .ProbeIssues}}条)</h3>
+<ul>{{range .ProbeIssues}}<li>{{.}}</li>{{end}}</ul>
+{{end}}
+{{if .Wildcards}}
+<h3>跳过的泛解析记录({{len .Wildcards}}条)</h3>
+<ul>{{range .Wildcards}}<li>{{.}}</li>{{end}}</ul>
+{{end}}
+</body>
+</html>
+`))
+
+// 定义邮件正文模板的数据
+type emailContent struct {
+	Summary      string
+	Sections     []NoticeSection
+	Expiry       []CertExpiry
+	ChainReports []ChainReport
+	ProbeIssues  []string
+	Wildcards    []string
+}
+
+// 定义邮件通知渠道
+type EmailNotifier struct {
+	host               string
+	port               int
+	security           string
+	username           string
+	password           string
+	from               *mail.Address
+	to                 []string
+	subject            string
+	insecureSkipVerify bool
+	timeout            time.Duration
+}
+
+/**
+* 根据notify.email配置创建邮件通知渠道
+ * @return Notifier
+ * @return error
+*/
+func NewEmailNotifier() (Notifier, error) {
+	return newEmailNotifier()
+}
+
+/**
+* 根据notify.email配置创建邮件通知渠道，团队邮件也使用该配置发送
+ * @return *EmailNotifier
+ * @return error
+*/
+func newEmailNotifier() (*EmailNotifier, error) {
+	host := viper.GetString("notify.email.host")
+	if host == "" {
+		return nil, errors.New("没有配置notify.email.host")
+	}
+	from, err := mail.ParseAddress(viper.GetString("notify.email.from"))
+	if err != nil {
+		return nil, fmt.Errorf("notify.email.from格式错误: %v", err)
+	}
+	security := strings.ToLower(viper.GetString("notify.email.security"))
+	switch security {
+	case EmailSecurityStartTLS, EmailSecurityTLS, EmailSecurityNone:
+	default:
+		return nil, fmt.Errorf("notify.email.security不支持%s，可选starttls、tls、none", security)
+	}
+
+	return &EmailNotifier{
+		host:               host,
+		port:               viper.GetInt("notify.email.port"),
+		security:           security,
+		username:           viper.GetString("notify.email.username"),
+		password:           viper.GetString("notify.email.password"),
+		from:               from,
+		to:                 viper.GetStringSlice("notify.email.to"),
+		subject:            viper.GetString("notify.email.subject"),
+		insecureSkipVerify: viper.GetBool("notify.email.insecure_skip_verify"),
+		timeout:            time.Duration(viper.GetInt("notify.email.timeout")) * time.Second,
+	}, nil
+}
+
+/**
+* 渠道名称
+ * @return string
+*/
+func (n *EmailNotifier) Name() string {
+	return "email"
+}
+
+/**
+* 发送汇总邮件：各阶段执行状态、证书到期、证书链异常、多地址证书异常、跳过的泛解析记录
+ * @param httpsDomainSum
+ * @param setpStatusMap
+ * @return error
+*/
+func (n *EmailNotifier) Notify(httpsDomainSum int, setpStatusMap map[string][]string) error {
+	if len(n.to) == 0 {
+		return errors.New("没有配置notify.email.to")
+	}
+
+	body, err := renderEmail(emailContent{
+		Summary:      fmt.Sprintf("本次已同步HTTPS域名%d条，请相关同事注意。", httpsDomainSum),
+		Sections:     noticeSections(setpStatusMap),
+		Expiry:       expirySlice,
+		ChainReports: chainReportSlice,
+		ProbeIssues:  probeIssueSlice,
+		Wildcards:    wildcardSlice,
+	})
+	if err != nil {
+		return err
+	}
+	return n.Send(n.to, n.subject, body)
+}
+
+/**
+* 发送团队证书到期提醒邮件，收件人为to(团队邮件时为ownership中的emails)
+ * @param team
+ * @param expiry
+ * @return error
+*/
+func (n *EmailNotifier) NotifyTeam(team string, expiry []CertExpiry) error {
+	if len(n.to) == 0 {
+		return errors.New("没有配置团队收件人")
+	}
+	body, err := renderEmail(emailContent{
+		Summary: fmt.Sprintf("【%s】本次检查发现证书到期提醒%d条，请相关同事注意。", team, len(expiry)),
+		Expiry:  expiry,
+	})
+	if err != nil {
+		return err
+	}
+	return n.Send(n.to, fmt.Sprintf("【%s】%s", team, n.subject), body)
+}
+
+/**
+* 通过SMTP发送HTML邮件，security为tls时直接建立TLS连接，为starttls时要求服务端支持STARTTLS
+ * @param to
+ * @param subject
+ * @param body
+ * @return error
+*/
+func (n *EmailNotifier) Send(to []string, subject string, body string) (_err error) {
+	var recipients []*mail.Address
+	for _, item := range to {
+		address, err := mail.ParseAddress(item)
+		if err != nil {
+			return fmt.Errorf("收件人格式错误 %s: %v", item, err)
+		}
+		recipients = append(recipients, address)
+	}
+
+	address := net.JoinHostPort(n.host, strconv.Itoa(n.port))
+	tlsConfig := &tls.Config{ServerName: n.host, InsecureSkipVerify: n.insecureSkipVerify}
+	dialer := &net.Dialer{Timeout: n.timeout}
+	var conn net.Conn
+	if n.security == EmailSecurityTLS {
+		conn, _err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	} else {
+		conn, _err = dialer.Dial("tcp", address)
+	}
+	if _err != nil {
+		return _err
+	}
+	// 整个会话共用一个超时，避免服务端无响应时一直阻塞
+	conn.SetDeadline(time.Now().Add(n.timeout))
+
+	client, _err := smtp.NewClient(conn, n.host)
+	if _err != nil {
+		conn.Close()
+		return _err
+	}
+	defer client.Close()
+
+	if n.security == EmailSecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("邮件服务器%s不支持STARTTLS", address)
+		}
+		if _err = client.StartTLS(tlsConfig); _err != nil {
+			return _err
+		}
+	}
+	// PlainAuth只允许在TLS连接或本机地址上发送密码
+	if n.username != "" {
+		if _err = client.Auth(smtp.PlainAuth("", n.username, n.password, n.host)); _err != nil {
+			return _err
+		}
+	}
+
+	if _err = client.Mail(n.from.Address); _err != nil {
+		return _err
+	}
+	for _, recipient := range recipients {
+		if _err = client.Rcpt(recipient.Address); _err != nil {
+			return fmt.Errorf("收件人%s被拒绝: %v", recipient.Address, _err)
+		}
+	}
+	writer, _err := client.Data()
+	if _err != nil {
+		return _err
+	}
+	if _, _err = writer.Write(n.message(recipients, subject, body)); _err != nil {
+		return _err
+	}
+	if _err = writer.Close(); _err != nil {
+		return _err
+	}
+	infologger.Printf("发送邮件成功: %s", strings.Join(to, ","))
+	return client.Quit()
+}
+
+/**
+* 生成邮件内容，标题按RFC 2047编码，正文为base64编码的HTML
+ * @param recipients
+ * @param subject
+ * @param body
+ * @return []byte
+*/
+func (n *EmailNotifier) message(recipients []*mail.Address, subject string, body string) []byte {
+	var to []string
+	for _, recipient := range recipients {
+		to = append(to, recipient.String())
+	}
+
+	var message bytes.Buffer
+	message.WriteString("From: " + n.from.String() + "\r\n")
+	message.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	message.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", subject) + "\r\n")
+	message.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
+	message.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+
+	// base64每行不超过76个字符
+	encoded := base64.StdEncoding.EncodeToString([]byte(body))
+	for len(encoded) > 76 {
+		message.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	message.WriteString(encoded + "\r\n")
+	return message.Bytes()
+}
+
+/**
+* 渲染邮件正文，证书到期列表按到期时间升序
+ * @param content
+ * @return string
+ * @return error
+*/
+func renderEmail(content emailContent) (string, error) {
+	content.Expiry = append([]CertExpiry(nil), content.Expiry...)
+	sort.Slice(content.Expiry, func(i, j int) bool {
+		return content.Expiry[i].NotAfter.Before(content.Expiry[j].NotAfter)
+	})
+
+	var body bytes.Buffer
+	if err := emailTemplate.Execute(&body, content); err != nil {
+		return "", err
+	}
+	return body.String(), nil
+}
+
+/**
+* 通知中的颜色名称转换成HTML颜色，与钉钉使用相同的颜色
+ * @param name
+ * @return string
+*/
+func emailColor(name string) string {
+	if color, ok := dingTalkColors[name]; ok {
+		return color
+	}
+	return "#333333"
+}
+
+/**
+* 证书到期分级的中文名称
+ * @param level
+ * @return string
+*/
+func certLevelText(level string) string {
+	switch level {
+	case CertLevelExpired:
+		return "已过期"
+	case CertLevelCritical:
+		return "紧急"
+	case CertLevelWarning:
+		return "即将到期"
+	}
+	return level
+}
+
+/**
+* 证书到期分级的HTML颜色
+ * @param level
+ * @return string
+*/
+func certLevelColor(level string) string {
+	if level == CertLevelWarning {
+		return emailColor("yellow")
+	}
+	return emailColor("red")
+}
@@ -0,0 +1,242 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 测试用SMTP服务端收到的会话内容
+type fakeSMTPSession struct {
+	auth       string
+	from       string
+	recipients []string
+	data       string
+	tls        bool
+}
+
+/**
+* 启动只接受一个连接的SMTP服务端，startTLS为true时在EHLO中声明STARTTLS
+ * @param t
+ * @param startTLS
+ * @return string
+ * @return func() fakeSMTPSession
+*/
+func startFakeSMTP(t *testing.T, startTLS bool) (string, func() fakeSMTPSession) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	var certificate tls.Certificate
+	if startTLS {
+		now := time.Now()
+		leaf := newTestCert(t, "127.0.0.1", []string{"localhost"}, false, now.Add(-time.Hour), now.Add(time.Hour), nil)
+		certificate = tls.Certificate{Certificate: [][]byte{leaf.cert.Raw}, PrivateKey: leaf.key}
+	}
+
+	var session fakeSMTPSession
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { conn.Close() }()
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		reply("220 fake ESMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch {
+			case command == "EHLO":
+				if startTLS && !session.tls {
+					reply("250-fake")
+					reply("250-STARTTLS")
+				} else {
+					reply("250-fake")
+				}
+				reply("250 AUTH PLAIN")
+			case command == "STARTTLS":
+				reply("220 ready")
+				tlsConn := tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{certificate}})
+				if err := tlsConn.Handshake(); err != nil {
+					return
+				}
+				conn = tlsConn
+				reader = bufio.NewReader(conn)
+				session.tls = true
+			case command == "AUTH":
+				session.auth = line
+				reply("235 ok")
+			case strings.HasPrefix(strings.ToUpper(line), "MAIL FROM:"):
+				session.from = line[len("MAIL FROM:"):]
+				reply("250 ok")
+			case strings.HasPrefix(strings.ToUpper(line), "RCPT TO:"):
+				session.recipients = append(session.recipients, line[len("RCPT TO:"):])
+				reply("250 ok")
+			case command == "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					dataLine, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if dataLine == ".\r\n" {
+						break
+					}
+					data.WriteString(dataLine)
+				}
+				session.data = data.String()
+				reply("250 queued")
+			case command == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("502 unsupported")
+			}
+		}
+	}()
+
+	return listener.Addr().String(), func() fakeSMTPSession {
+		wg.Wait()
+		return session
+	}
+}
+
+/**
+* 根据测试服务端地址创建邮件通知渠道
+ * @param t
+ * @param address
+ * @param security
+ * @return *EmailNotifier
+*/
+func newTestEmailNotifier(t *testing.T, address string, security string) *EmailNotifier {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNumber, _ := net.LookupPort("tcp", port)
+	from, _ := mail.ParseAddress("HTTPS域名检查 <monitor@example.com>")
+	return &EmailNotifier{
+		host:               host,
+		port:               portNumber,
+		security:           security,
+		username:           "monitor@example.com",
+		password:           "secret",
+		from:               from,
+		insecureSkipVerify: true,
+		timeout:            5 * time.Second,
+	}
+}
+
+/**
+* 从邮件内容中取出base64编码的HTML正文
+ * @param t
+ * @param data
+ * @return string
+*/
+func decodeEmailBody(t *testing.T, data string) string {
+	parts := strings.SplitN(data, "\r\n\r\n", 2)
+	if len(parts) != 2 {
+		t.Fatalf("邮件内容没有正文: %q", data)
+	}
+	body, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(parts[1], "\r\n", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+/**
+* 校验一次发送的收件人、认证、标题编码和正文
+ * @param t
+ * @param session
+*/
+func checkEmailSession(t *testing.T, session fakeSMTPSession) {
+	if session.from != "<monitor@example.com>" {
+		t.Errorf("MAIL FROM = %s", session.from)
+	}
+	if strings.Join(session.recipients, ",") != "<ops@example.com>,<dev@example.com>" {
+		t.Errorf("RCPT TO = %v", session.recipients)
+	}
+	if want := "AUTH PLAIN " + base64.StdEncoding.EncodeToString([]byte("\x00monitor@example.com\x00secret")); session.auth != want {
+		t.Errorf("AUTH = %s, want %s", session.auth, want)
+	}
+	if !strings.Contains(session.data, "Subject: =?UTF-8?b?") {
+		t.Errorf("标题没有按RFC 2047编码: %q", session.data)
+	}
+	if !strings.Contains(session.data, "To: <ops@example.com>, =?utf-8?q?=E8=BF=90=E7=BB=B4?= <dev@example.com>\r\n") {
+		t.Errorf("To头错误: %q", session.data)
+	}
+	if body := decodeEmailBody(t, session.data); body != "<p>证书到期提醒</p>" {
+		t.Errorf("正文 = %q", body)
+	}
+}
+
+/**
+* security为none时明文发送，本机地址上允许PLAIN认证
+ * @param t
+*/
+func TestEmailSendNone(t *testing.T) {
+	address, wait := startFakeSMTP(t, false)
+	notifier := newTestEmailNotifier(t, address, EmailSecurityNone)
+	if err := notifier.Send([]string{"ops@example.com", "运维 <dev@example.com>"}, "HTTPS域名检查", "<p>证书到期提醒</p>"); err != nil {
+		t.Fatal(err)
+	}
+	session := wait()
+	if session.tls {
+		t.Fatal("none模式不应升级TLS")
+	}
+	checkEmailSession(t, session)
+}
+
+/**
+* security为starttls时先升级TLS再认证发送
+ * @param t
+*/
+func TestEmailSendStartTLS(t *testing.T) {
+	address, wait := startFakeSMTP(t, true)
+	notifier := newTestEmailNotifier(t, address, EmailSecurityStartTLS)
+	if err := notifier.Send([]string{"ops@example.com", "运维 <dev@example.com>"}, "HTTPS域名检查", "<p>证书到期提醒</p>"); err != nil {
+		t.Fatal(err)
+	}
+	session := wait()
+	if !session.tls {
+		t.Fatal("starttls模式没有升级TLS")
+	}
+	checkEmailSession(t, session)
+}
+
+/**
+* 服务端不支持STARTTLS时不能回退成明文发送
+ * @param t
+*/
+func TestEmailSendStartTLSNotSupported(t *testing.T) {
+	address, _ := startFakeSMTP(t, false)
+	notifier := newTestEmailNotifier(t, address, EmailSecurityStartTLS)
+	err := notifier.Send([]string{"ops@example.com"}, "HTTPS域名检查", "<p>证书到期提醒</p>")
+	if err == nil || !strings.Contains(err.Error(), "不支持STARTTLS") {
+		t.Fatalf("Send错误 = %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// 通知内容中企业微信的颜色写法转换成飞书lark_md支持的颜色
+var feishuColors = map[string]string{
+	"red":     "red",
+	"green":   "green",
+	"yellow":  "orange",
+	"warning": "orange",
+	"info":    "green",
+	"comment": "grey",
+}
+
+// 定义飞书/Lark群机器人通知渠道，webhook为飞书(open.feishu.cn)或Lark(open.larksuite.com)的地址
+type FeishuNotifier struct {
+	webhook string
+	secret  string
+}
+
+// 定义飞书机器人消息卡片
+type feishuMessage struct {
+	Timestamp string     `json:"timestamp,omitempty"`
+	Sign      string     `json:"sign,omitempty"`
+	MsgType   string     `json:"msg_type"`
+	Card      feishuCard `json:"card"`
+}
+
+// 定义消息卡片，header.template为标题栏颜色
+type feishuCard struct {
+	Config struct {
+		WideScreenMode bool `json:"wide_screen_mode"`
+	} `json:"config"`
+	Header struct {
+		Title    feishuText `json:"title"`
+		Template string     `json:"template"`
+	} `json:"header"`
+	Elements []feishuElement `json:"elements"`
+}
+
+// 定义卡片中的元素，div为文本，hr为分割线
+type feishuElement struct {
+	Tag  string      `json:"tag"`
+	Text *feishuText `json:"text,omitempty"`
+}
+
+// 定义卡片中的文本，tag为plain_text或lark_md
+type feishuText struct {
+	Tag     string `json:"tag"`
+	Content string `json:"content"`
+}
+
+// 定义飞书机器人接口出参，旧版本接口返回StatusCode
+type feishuResponse struct {
+	Code       *int   `json:"code"`
+	Msg        string `json:"msg"`
+	StatusCode *int   `json:"StatusCode"`
+}
+
+/**
+* 根据notify.feishu配置创建飞书通知渠道
+ * @return Notifier
+ * @return error
+*/
+func NewFeishuNotifier() (Notifier, error) {
+	webhook := viper.GetString("notify.feishu.webhook")
+	if webhook == "" {
+		return nil, errors.New("没有配置notify.feishu.webhook")
+	}
+	return &FeishuNotifier{
+		webhook: webhook,
+		secret:  viper.GetString("notify.feishu.secret"),
+	}, nil
+}
+
+/**
+* 渠道名称
+ * @return string
+*/
+func (n *FeishuNotifier) Name() string {
+	return "feishu"
+}
+
+/**
+* 发送飞书消息卡片：标题栏按执行结果着色(有阶段失败为红色，有证书问题为橙色，否则为绿色)，每个阶段的状态单独着色
+ * @param httpsDomainSum
+ * @param setpStatusMap
+ * @return error
+*/
+func (n *FeishuNotifier) Notify(httpsDomainSum int, setpStatusMap map[string][]string) error {
+	sections := noticeSections(setpStatusMap)
+
+	var card feishuCard
+	card.Config.WideScreenMode = true
+	card.Header.Title = feishuText{Tag: "plain_text", Content: "HTTPS域名同步通知"}
+	switch {
+	case hasFailedStep(sections):
+		card.Header.Template = "red"
+	case len(expirySlice) > 0 || len(chainReportSlice) > 0 || len(probeIssueSlice) > 0:
+		card.Header.Template = "orange"
+	default:
+		card.Header.Template = "green"
+	}
+
+	card.Elements = append(card.Elements, feishuDiv(fmt.Sprintf("本次已同步HTTPS域名<font color='orange'>%d条</font>，请相关同事注意。", httpsDomainSum)))
+	for _, section := range sections {
+		lines := []string{fmt.Sprintf("**【%s】**", section.Title)}
+		for _, step := range section.Steps {
+			color := feishuColors[step.Status[1]]
+			if color == "" {
+				color = step.Status[1]
+			}
+			lines = append(lines, fmt.Sprintf("%s: <font color='%s'>%s</font>", step.Name, color, step.Status[0]))
+		}
+		card.Elements = append(card.Elements, feishuDiv(strings.Join(lines, "\n")))
+	}
+
+	// 证书到期等明细
+	if details := feishuMarkdown(noticeDetails()); details != "" {
+		card.Elements = append(card.Elements, feishuElement{Tag: "hr"}, feishuDiv(details))
+	}
+	return n.Send(card)
+}
+
+/**
+* 发送飞书团队证书到期提醒，有已过期或紧急的证书时标题栏为红色，否则为橙色
+ * @param team
+ * @param expiry
+ * @return error
+*/
+func (n *FeishuNotifier) NotifyTeam(team string, expiry []CertExpiry) error {
+	var card feishuCard
+	card.Config.WideScreenMode = true
+	card.Header.Title = feishuText{Tag: "plain_text", Content: fmt.Sprintf("【%s】证书到期提醒", team)}
+	card.Header.Template = "orange"
+	for _, item := range expiry {
+		if item.Level == CertLevelExpired || item.Level == CertLevelCritical {
+			card.Header.Template = "red"
+			break
+		}
+	}
+	card.Elements = append(card.Elements, feishuDiv(feishuMarkdown(teamNoticeMarkdown(team, expiry))))
+	return n.Send(card)
+}
+
+/**
+* 发送消息卡片，配置了secret时在请求体中加上timestamp和sign
+ * @param card
+ * @return error
+*/
+func (n *FeishuNotifier) Send(card feishuCard) (_err error) {
+	message := feishuMessage{MsgType: "interactive", Card: card}
+	if n.secret != "" {
+		message.Timestamp, message.Sign = signFeishu(n.secret, time.Now())
+	}
+
+	messageBytes, _err := json.Marshal(message)
+	if _err != nil {
+		return _err
+	}
+	resp, _err := webhookClient.Post(n.webhook, "application/json", bytes.NewBuffer(messageBytes))
+	if _err != nil {
+		return _err
+	}
+	defer resp.Body.Close()
+
+	body, _err := ioutil.ReadAll(resp.Body)
+	if _err != nil {
+		return _err
+	}
+	infologger.Printf("调用飞书接口状态码: %s", resp.Status)
+	infologger.Printf("调用飞书接口出参: %s", string(body))
+
+	// 签名校验失败等错误状态码也是200，需要检查code
+	var result feishuResponse
+	if _err = json.Unmarshal(body, &result); _err != nil {
+		return fmt.Errorf("飞书接口返回%s: %s", resp.Status, string(body))
+	}
+	if result.Code != nil && *result.Code != 0 {
+		return fmt.Errorf("飞书接口返回错误%d: %s", *result.Code, result.Msg)
+	}
+	if result.StatusCode != nil && *result.StatusCode != 0 {
+		return fmt.Errorf("飞书接口返回错误%d: %s", *result.StatusCode, result.Msg)
+	}
+	return nil
+}
+
+/**
+* 飞书机器人签名：以timestamp+"\n"+secret为密钥对空字符串做HmacSHA256，再Base64，timestamp为秒
+ * @param secret
+ * @param now
+ * @return string timestamp
+ * @return string sign
+*/
+func signFeishu(secret string, now time.Time) (string, string) {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(timestamp+"\n"+secret))
+	mac.Write([]byte{})
+	return timestamp, base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+/**
+* 创建lark_md文本元素
+ * @param content
+ * @return feishuElement
+*/
+func feishuDiv(content string) feishuElement {
+	return feishuElement{Tag: "div", Text: &feishuText{Tag: "lark_md", Content: content}}
+}
+
+/**
+* 企业微信格式的Markdown转换成飞书lark_md：颜色转成飞书支持的颜色，去掉行首缩进和引用符号，标题加粗
+ * @param content
+ * @return string
+*/
+func feishuMarkdown(content string) string {
+	content = fontColorRegexp.ReplaceAllStringFunc(content, func(match string) string {
+		name := fontColorRegexp.FindStringSubmatch(match)[1]
+		if color, ok := feishuColors[name]; ok {
+			return `<font color='` + color + `'>`
+		}
+		return match
+	})
+
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), ">"))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "【") {
+			if i := strings.Index(line, "】"); i >= 0 {
+				end := i + len("】")
+				line = "**" + line[:end] + "**" + line[end:]
+			}
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,88 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+/**
+* 飞书签名的已知向量，期望值按飞书文档的算法独立计算
+ * @param t
+*/
+func TestSignFeishu(t *testing.T) {
+	timestamp, sign := signFeishu("feishu-secret", time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC))
+	if timestamp != "1792137600" {
+		t.Fatalf("timestamp = %s, want 1792137600", timestamp)
+	}
+	if want := "YMmNXOmPJJ8b2GUrLpQIFniWGSil8JgRnuS72cDTYtw="; sign != want {
+		t.Fatalf("sign = %s, want %s", sign, want)
+	}
+}
+
+/**
+* 发送消息卡片：阶段失败时标题栏为红色，请求体带签名，code不为0时返回错误
+ * @param t
+*/
+func TestFeishuNotifierNotify(t *testing.T) {
+	var message feishuMessage
+	response := `{"code":0,"msg":"success"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &message)
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	notifier := &FeishuNotifier{webhook: server.URL, secret: "feishu-secret"}
+	statusMap := map[string][]string{
+		"expirationHttpsDomainStatus": {successText, successColor},
+		"reloadPrometheusStatus":      {failText, failColor},
+	}
+	if err := notifier.Notify(3, statusMap); err != nil {
+		t.Fatal(err)
+	}
+	if message.MsgType != "interactive" || message.Timestamp == "" || message.Sign == "" {
+		t.Fatalf("消息缺少类型或签名: %+v", message)
+	}
+	if message.Card.Header.Template != "red" {
+		t.Fatalf("标题栏颜色 = %s, want red", message.Card.Header.Template)
+	}
+	var contents []string
+	for _, element := range message.Card.Elements {
+		if element.Text != nil {
+			contents = append(contents, element.Text.Content)
+		}
+	}
+	content := strings.Join(contents, "\n")
+	for _, want := range []string{"**【HTTPS域名检查】**", "5、Reload状态: <font color='red'>执行失败</font>", "4、检查HTTPS域名到期时间: <font color='green'>执行完成</font>"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("卡片内容中没有%q: %s", want, content)
+		}
+	}
+
+	response = `{"code":19021,"msg":"sign match fail or timestamp is not within one hour from current time"}`
+	if err := notifier.Notify(3, statusMap); err == nil || !strings.HasPrefix(err.Error(), "飞书接口返回错误19021") {
+		t.Fatalf("Notify错误 = %v", err)
+	}
+}
+
+/**
+* 企业微信格式的明细转换成lark_md
+ * @param t
+*/
+func TestFeishuMarkdown(t *testing.T) {
+	got := feishuMarkdown("\n\n\t\t> 【证书链异常】<font color=\"red\">1条</font>\n\t\t> www.example.com: 缺少中间证书")
+	want := "**【证书链异常】**<font color='red'>1条</font>\nwww.example.com: 缺少中间证书"
+	if got != want {
+		t.Fatalf("feishuMarkdown = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,67 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+/**
+* webhook接口无响应时，企业微信、钉钉、飞书都在webhook_timeout后返回错误，不会一直阻塞通知阶段
+ * @param t
+*/
+func TestWebhookTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	webhookClient.Timeout = 100 * time.Millisecond
+	t.Cleanup(func() { setWebhookTimeout(0) })
+
+	senders := map[string]func() error{
+		"wecom": func() error {
+			return sendWeComMarkdown(server.URL, "content")
+		},
+		"dingtalk": func() error {
+			return (&DingTalkNotifier{webhook: server.URL}).Send("title", "text")
+		},
+		"feishu": func() error {
+			return (&FeishuNotifier{webhook: server.URL}).Send(feishuCard{})
+		},
+	}
+	for name, send := range senders {
+		start := time.Now()
+		err := send()
+		if err == nil {
+			t.Errorf("%s: 接口无响应时没有返回错误", name)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("%s: 等待了%s才返回", name, elapsed)
+		}
+	}
+}
+
+/**
+* 超时时间小于等于0时使用默认值
+ * @param t
+*/
+func TestSetWebhookTimeout(t *testing.T) {
+	t.Cleanup(func() { setWebhookTimeout(0) })
+
+	setWebhookTimeout(3)
+	if webhookClient.Timeout != 3*time.Second {
+		t.Fatalf("Timeout = %s, want 3s", webhookClient.Timeout)
+	}
+	setWebhookTimeout(0)
+	if webhookClient.Timeout != defaultWebhookTimeout {
+		t.Fatalf("Timeout = %s, want %s", webhookClient.Timeout, defaultWebhookTimeout)
+	}
+}
@@ -0,0 +1,222 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// 归属标签，写入blackbox targets和报表的labels
+const (
+	OwnerLabelTeam     = "team"
+	OwnerLabelEnv      = "env"
+	OwnerLabelBusiness = "business"
+)
+
+// 定义一条归属规则，provider、zone、host、remark为空表示不限制，zone、host、remark支持glob，按配置顺序第一条匹配的规则生效
+// remark匹配的是DNS服务商中解析记录的备注(阿里云备注、DNSPod备注、Cloudflare comment、华为云description)
+type OwnerRule struct {
+	Team     string   `mapstructure:"team"`
+	Env      string   `mapstructure:"env"`
+	Business string   `mapstructure:"business"`
+	Channel  string   `mapstructure:"channel"`
+	Webhook  string   `mapstructure:"webhook"`
+	Secret   string   `mapstructure:"secret"`
+	Emails   []string `mapstructure:"emails"`
+	Provider string   `mapstructure:"provider"`
+	Zone     string   `mapstructure:"zone"`
+	Host     string   `mapstructure:"host"`
+	Remark   string   `mapstructure:"remark"`
+}
+
+// 当前生效的归属规则，每次同步域名清单时重新读取
+var ownerRules []OwnerRule
+
+/**
+* 读取ownership配置并检查glob格式
+ * @return []OwnerRule
+ * @return error
+*/
+func LoadOwnerRules() (rules []OwnerRule, _err error) {
+	if _err = viper.UnmarshalKey("ownership", &rules); _err != nil {
+		return nil, _err
+	}
+	for _, rule := range rules {
+		if rule.Team == "" {
+			return nil, fmt.Errorf("ownership中team不能为空: %+v", rule)
+		}
+		if _, ok := teamNotifierFactories[rule.channel()]; !ok {
+			return nil, fmt.Errorf("ownership中%s的channel不支持%s，可选wecom、dingtalk、feishu", rule.Team, rule.Channel)
+		}
+		for _, pattern := range []string{rule.Zone, rule.Host, rule.Remark} {
+			if _, _err = path.Match(pattern, ""); _err != nil {
+				return nil, fmt.Errorf("ownership中%s的匹配规则格式错误 %s: %v", rule.Team, pattern, _err)
+			}
+		}
+	}
+	return rules, nil
+}
+
+/**
+* 判断归属规则是否匹配解析记录
+ * @param record
+ * @return bool
+*/
+func (r OwnerRule) Match(record DomainRecord) bool {
+	if r.Provider != "" && r.Provider != record.Provider {
+		return false
+	}
+	for _, item := range [][2]string{{r.Zone, record.Zone}, {r.Host, record.Host()}, {r.Remark, record.Remark}} {
+		if item[0] == "" {
+			continue
+		}
+		if matched, _ := path.Match(strings.ToLower(item[0]), strings.ToLower(item[1])); !matched {
+			return false
+		}
+	}
+	return true
+}
+
+/**
+* 查找解析记录的归属规则
+ * @param record
+ * @return *OwnerRule
+*/
+func findOwner(record DomainRecord) *OwnerRule {
+	for i := range ownerRules {
+		if ownerRules[i].Match(record) {
+			return &ownerRules[i]
+		}
+	}
+	return nil
+}
+
+/**
+* 给解析记录加上team/env/business标签，清单中已经带了的标签不覆盖
+ * @param record
+ * @return DomainRecord
+*/
+func applyOwnership(record DomainRecord) DomainRecord {
+	owner := findOwner(record)
+	if owner == nil {
+		return record
+	}
+
+	labels := make(map[string]string)
+	for key, value := range record.Labels {
+		labels[key] = value
+	}
+	for key, value := range map[string]string{OwnerLabelTeam: owner.Team, OwnerLabelEnv: owner.Env, OwnerLabelBusiness: owner.Business} {
+		if _, exists := labels[key]; !exists && value != "" {
+			labels[key] = value
+		}
+	}
+	record.Labels = labels
+	return record
+}
+
+/**
+* 团队通知渠道，没有配置时为企业微信
+ * @return string
+*/
+func (r OwnerRule) channel() string {
+	if r.Channel == "" {
+		return "wecom"
+	}
+	return strings.ToLower(r.Channel)
+}
+
+/**
+* 生成团队证书到期提醒的Markdown内容，颜色使用企业微信的写法，其它渠道按需转换
+ * @param team
+ * @param expiry
+ * @return string
+*/
+func teamNoticeMarkdown(team string, expiry []CertExpiry) string {
+	content := fmt.Sprintf(`【%s】本次检查发现证书到期提醒<font color="warning">%d条</font>，请相关同事注意。`, team, len(expiry))
+	return content + expiryNoticeContent(expiry)
+}
+
+/**
+* 获取探测目标所属的团队
+ * @param target
+ * @return string
+*/
+func targetTeam(target string) string {
+	if i, ok := recordIndex[target]; ok {
+		return recordSlice[i].Labels[OwnerLabelTeam]
+	}
+	return ""
+}
+
+/**
+* 按团队把证书到期提醒发送到各团队配置的webhook(企业微信、钉钉、飞书)和邮箱，都没有配置的团队只在汇总通知中体现
+ * @return error
+*/
+func NoticeTeams() (_err error) {
+	teamExpiry := make(map[string][]CertExpiry)
+	for _, item := range expirySlice {
+		if team := targetTeam(item.Target); team != "" {
+			teamExpiry[team] = append(teamExpiry[team], item)
+		}
+	}
+
+	// 同一个团队可能有多条归属规则，同一个webhook只发送一次，各规则的收件人合并后每个团队只发送一封邮件
+	var emailTeams []string
+	teamEmails := make(map[string][]string)
+	sentWebhook := make(map[string]bool)
+	addedEmail := make(map[string]bool)
+	for _, rule := range ownerRules {
+		if len(teamExpiry[rule.Team]) == 0 {
+			continue
+		}
+
+		if rule.Webhook != "" && !sentWebhook[rule.Team+"|"+rule.Webhook] {
+			sentWebhook[rule.Team+"|"+rule.Webhook] = true
+			notifier := teamNotifierFactories[rule.channel()](rule)
+			if err := notifier.NotifyTeam(rule.Team, teamExpiry[rule.Team]); err != nil {
+				errlogger.Printf("发送%s团队%s通知失败: %v", rule.Team, notifier.Name(), err)
+				_err = err
+			} else {
+				infologger.Printf("发送%s团队%s通知成功", rule.Team, notifier.Name())
+			}
+		}
+
+		for _, email := range rule.Emails {
+			key := rule.Team + "|" + strings.ToLower(strings.TrimSpace(email))
+			if addedEmail[key] {
+				continue
+			}
+			addedEmail[key] = true
+			if len(teamEmails[rule.Team]) == 0 {
+				emailTeams = append(emailTeams, rule.Team)
+			}
+			teamEmails[rule.Team] = append(teamEmails[rule.Team], email)
+		}
+	}
+
+	var emailNotifier *EmailNotifier
+	for _, team := range emailTeams {
+		if emailNotifier == nil {
+			if emailNotifier, _err = newEmailNotifier(); _err != nil {
+				errlogger.Printf("发送%s团队邮件失败: %v", team, _err)
+				return _err
+			}
+		}
+		teamNotifier := *emailNotifier
+		teamNotifier.to = teamEmails[team]
+		if err := teamNotifier.NotifyTeam(team, teamExpiry[team]); err != nil {
+			errlogger.Printf("发送%s团队邮件失败: %v", team, err)
+			_err = err
+		} else {
+			infologger.Printf("发送%s团队邮件成功", team)
+		}
+	}
+	return _err
+}
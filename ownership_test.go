@@ -0,0 +1,155 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+/**
+* channel为空时默认企业微信，不支持的channel在读取配置时报错
+ * @param t
+*/
+func TestLoadOwnerRulesChannel(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	viper.Set("ownership", []map[string]interface{}{
+		{"team": "web", "webhook": "http://127.0.0.1/wecom"},
+		{"team": "pay", "channel": "DingTalk", "webhook": "http://127.0.0.1/dingtalk", "secret": "SECexample"},
+	})
+	rules, err := LoadOwnerRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules[0].channel() != "wecom" || rules[1].channel() != "dingtalk" || rules[1].Secret != "SECexample" {
+		t.Fatalf("归属规则错误: %+v", rules)
+	}
+
+	viper.Set("ownership", []map[string]interface{}{{"team": "web", "channel": "slack"}})
+	if _, err := LoadOwnerRules(); err == nil || !strings.Contains(err.Error(), "channel不支持slack") {
+		t.Fatalf("LoadOwnerRules错误 = %v", err)
+	}
+}
+
+/**
+* 各团队的到期提醒按ownership中的channel分别发送到企业微信、钉钉和飞书，同一个webhook只发送一次
+ * @param t
+*/
+func TestNoticeTeamsChannels(t *testing.T) {
+	oldRecords, oldIndex, oldExpiry, oldRules := recordSlice, recordIndex, expirySlice, ownerRules
+	t.Cleanup(func() {
+		recordSlice, recordIndex, expirySlice, ownerRules = oldRecords, oldIndex, oldExpiry, oldRules
+	})
+
+	received := make(map[string][]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received[r.URL.Path] = append(received[r.URL.Path], string(body))
+		switch r.URL.Path {
+		case "/dingtalk":
+			if r.URL.Query().Get("sign") == "" {
+				t.Errorf("钉钉请求没有签名: %s", r.URL)
+			}
+			w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+		case "/feishu":
+			w.Write([]byte(`{"code":0,"msg":"success"}`))
+		default:
+			w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+		}
+	}))
+	defer server.Close()
+
+	recordSlice = []DomainRecord{
+		{RR: "www", Zone: "example.com", Labels: map[string]string{OwnerLabelTeam: "web"}},
+		{RR: "pay", Zone: "example.com", Labels: map[string]string{OwnerLabelTeam: "pay"}},
+		{RR: "ops", Zone: "example.com", Labels: map[string]string{OwnerLabelTeam: "ops"}},
+	}
+	recordIndex = map[string]int{"www.example.com:443": 0, "pay.example.com:443": 1, "ops.example.com:443": 2}
+	notAfter := time.Now().Add(24 * time.Hour)
+	expirySlice = []CertExpiry{
+		{Target: "www.example.com:443", NotAfter: notAfter, DaysLeft: 1, Level: CertLevelCritical},
+		{Target: "pay.example.com:443", NotAfter: notAfter, DaysLeft: 1, Level: CertLevelCritical},
+		{Target: "ops.example.com:443", NotAfter: notAfter, DaysLeft: 1, Level: CertLevelCritical},
+	}
+	ownerRules = []OwnerRule{
+		{Team: "web", Webhook: server.URL + "/wecom", Zone: "example.com"},
+		{Team: "web", Webhook: server.URL + "/wecom", Host: "www*"},
+		{Team: "pay", Channel: "dingtalk", Webhook: server.URL + "/dingtalk", Secret: "SECexample"},
+		{Team: "ops", Channel: "feishu", Webhook: server.URL + "/feishu"},
+	}
+	if err := NoticeTeams(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(received["/wecom"]) != 1 || !strings.Contains(received["/wecom"][0], "【web】") {
+		t.Fatalf("企业微信团队通知错误: %v", received["/wecom"])
+	}
+	var dingTalk dingTalkMessage
+	if len(received["/dingtalk"]) != 1 {
+		t.Fatalf("钉钉团队通知次数 = %d, want 1", len(received["/dingtalk"]))
+	}
+	json.Unmarshal([]byte(received["/dingtalk"][0]), &dingTalk)
+	if dingTalk.Markdown.Title != "【pay】证书到期提醒" || !strings.Contains(dingTalk.Markdown.Text, "pay.example.com:443") {
+		t.Fatalf("钉钉团队通知内容错误: %+v", dingTalk)
+	}
+	var feishu feishuMessage
+	if len(received["/feishu"]) != 1 {
+		t.Fatalf("飞书团队通知次数 = %d, want 1", len(received["/feishu"]))
+	}
+	json.Unmarshal([]byte(received["/feishu"][0]), &feishu)
+	if feishu.Card.Header.Title.Content != "【ops】证书到期提醒" || feishu.Card.Header.Template != "red" {
+		t.Fatalf("飞书团队通知标题错误: %+v", feishu.Card.Header)
+	}
+	if len(feishu.Card.Elements) == 0 || feishu.Card.Elements[0].Text == nil || !strings.Contains(feishu.Card.Elements[0].Text.Content, "ops.example.com:443") {
+		t.Fatalf("飞书团队通知内容错误: %+v", feishu.Card.Elements)
+	}
+}
+
+/**
+* 同一个团队的多条归属规则配置了不同收件人时合并发送一封邮件，重复的地址只发送一次
+ * @param t
+*/
+func TestNoticeTeamsMergeEmails(t *testing.T) {
+	oldRecords, oldIndex, oldExpiry, oldRules := recordSlice, recordIndex, expirySlice, ownerRules
+	viper.Reset()
+	t.Cleanup(func() {
+		recordSlice, recordIndex, expirySlice, ownerRules = oldRecords, oldIndex, oldExpiry, oldRules
+		viper.Reset()
+	})
+
+	address, wait := startFakeSMTP(t, false)
+	host, port, _ := net.SplitHostPort(address)
+	viper.Set("notify.email.host", host)
+	viper.Set("notify.email.port", port)
+	viper.Set("notify.email.security", EmailSecurityNone)
+	viper.Set("notify.email.from", "monitor@example.com")
+	viper.Set("notify.email.timeout", 5)
+
+	recordSlice = []DomainRecord{{RR: "www", Zone: "example.com", Labels: map[string]string{OwnerLabelTeam: "web"}}}
+	recordIndex = map[string]int{"www.example.com:443": 0}
+	expirySlice = []CertExpiry{{Target: "www.example.com:443", NotAfter: time.Now().Add(24 * time.Hour), DaysLeft: 1, Level: CertLevelCritical}}
+	ownerRules = []OwnerRule{
+		{Team: "web", Emails: []string{"web@example.com", "oncall@example.com"}, Zone: "example.com"},
+		{Team: "web", Emails: []string{"Web@example.com", "lead@example.com"}, Host: "www*"},
+	}
+	if err := NoticeTeams(); err != nil {
+		t.Fatal(err)
+	}
+
+	session := wait()
+	if got, want := strings.Join(session.recipients, ","), "<web@example.com>,<oncall@example.com>,<lead@example.com>"; got != want {
+		t.Fatalf("RCPT TO = %s, want %s", got, want)
+	}
+}
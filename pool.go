@@ -0,0 +1,168 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 探测时使用的限流器，nil表示不限流
+var probeLimiter *ProbeLimiter
+
+// 定义探测限流器，限制全局握手速率和同一个IP的并发握手数，避免大量域名解析到同一个SLB/CDN时触发防火墙
+type ProbeLimiter struct {
+	interval time.Duration
+	perIP    int
+
+	mutex sync.Mutex
+	next  time.Time
+	ipSem map[string]chan struct{}
+}
+
+/**
+* 创建探测限流器
+ * @param rate 每秒最多发起的握手数，小于等于0表示不限制
+ * @param perIP 同一个IP同时进行的握手数，小于等于0表示不限制
+ * @return *ProbeLimiter
+*/
+func NewProbeLimiter(rate int, perIP int) *ProbeLimiter {
+	limiter := &ProbeLimiter{
+		perIP: perIP,
+		ipSem: make(map[string]chan struct{}),
+	}
+	if rate > 0 {
+		limiter.interval = time.Second / time.Duration(rate)
+	}
+	return limiter
+}
+
+/**
+* 按全局速率等待，直到可以发起下一次握手
+ */
+func (l *ProbeLimiter) Wait() {
+	if l == nil || l.interval == 0 {
+		return
+	}
+
+	// 预约下一个可用的时间点，按预约的时间点依次放行
+	l.mutex.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mutex.Unlock()
+
+	time.Sleep(wait)
+}
+
+/**
+* 占用一个IP的并发名额，名额用完时等待，返回释放名额的函数
+ * @param ip
+ * @return func()
+*/
+func (l *ProbeLimiter) AcquireIP(ip string) func() {
+	if l == nil || l.perIP <= 0 {
+		return func() {}
+	}
+
+	l.mutex.Lock()
+	sem, ok := l.ipSem[ip]
+	if !ok {
+		sem = make(chan struct{}, l.perIP)
+		l.ipSem[ip] = sem
+	}
+	l.mutex.Unlock()
+
+	sem <- struct{}{}
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-sem })
+	}
+}
+
+/**
+* 按限流配置建立TCP连接，先解析出IP再占用该IP的并发名额，避免等待名额的时间算进连接超时
+* 域名解析出多个地址时按顺序尝试，返回的函数用于握手结束后释放名额，返回的耗时只包含最后一次成功的连接
+ * @param network tcp/tcp4/tcp6
+ * @param address
+ * @return net.Conn
+ * @return func()
+ * @return time.Duration
+ * @return error
+*/
+func dialProbe(network string, address string) (conn net.Conn, release func(), connectTime time.Duration, _err error) {
+	host, port, _err := net.SplitHostPort(address)
+	if _err != nil {
+		return nil, nil, 0, _err
+	}
+
+	ips := []string{host}
+	if net.ParseIP(host) == nil {
+		ipNetwork := "ip"
+		switch network {
+		case "tcp4":
+			ipNetwork = "ip4"
+		case "tcp6":
+			ipNetwork = "ip6"
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+		addrs, _err := lookupProbeIP(ctx, ipNetwork, host)
+		cancel()
+		if _err != nil {
+			return nil, nil, 0, _err
+		}
+		if len(addrs) == 0 {
+			return nil, nil, 0, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+		}
+		ips = nil
+		for _, addr := range addrs {
+			ips = append(ips, addr.String())
+		}
+	}
+
+	for _, ip := range ips {
+		probeLimiter.Wait()
+		release = probeLimiter.AcquireIP(ip)
+		dialStart := time.Now()
+		conn, _err = net.DialTimeout(network, net.JoinHostPort(ip, port), probeTimeout)
+		if _err == nil {
+			return conn, release, time.Since(dialStart), nil
+		}
+		release()
+	}
+	return nil, nil, 0, _err
+}
+
+/**
+* 定时把探测进度写入info.log，stop关闭时停止
+ * @param done 已完成的数量
+ * @param total 总数
+ * @param interval
+ * @param stop
+*/
+func reportProgress(done *int64, total int, interval time.Duration, stop <-chan struct{}) {
+	if interval <= 0 {
+		return
+	}
+
+	start := time.Now()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			infologger.Printf("探测进度: %d/%d，耗时%s", atomic.LoadInt64(done), total, time.Since(start).Round(time.Second))
+			return
+		case <-ticker.C:
+			infologger.Printf("探测进度: %d/%d，耗时%s", atomic.LoadInt64(done), total, time.Since(start).Round(time.Second))
+		}
+	}
+}
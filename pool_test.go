@@ -0,0 +1,133 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+/**
+* 启动只接受连接的本地TCP服务，返回地址
+ * @param t
+ * @return string
+*/
+func startTCPServer(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				buf := make([]byte, 1)
+				conn.Read(buf)
+				conn.Close()
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+/**
+* 同一个IP同时进行的连接数不超过per_ip_limit
+ * @param t
+*/
+func TestDialProbePerIPLimit(t *testing.T) {
+	address := startTCPServer(t)
+	probeLimiter = NewProbeLimiter(0, 3)
+	t.Cleanup(func() { probeLimiter = nil })
+
+	var active, maxActive int64
+	var wg sync.WaitGroup
+	for i := 0; i < 12; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn, release, _, err := dialProbe("tcp", address)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			current := atomic.AddInt64(&active, 1)
+			for {
+				previous := atomic.LoadInt64(&maxActive)
+				if current <= previous || atomic.CompareAndSwapInt64(&maxActive, previous, current) {
+					break
+				}
+			}
+			// 模拟握手耗时，名额在握手结束后释放
+			time.Sleep(30 * time.Millisecond)
+			atomic.AddInt64(&active, -1)
+			conn.Close()
+			release()
+		}()
+	}
+	wg.Wait()
+
+	if maxActive != 3 {
+		t.Fatalf("同一个IP最大并发连接数 = %d, want 3", maxActive)
+	}
+}
+
+/**
+* 全局握手速率按rate_limit限制，名额释放后可以重复使用
+ * @param t
+*/
+func TestDialProbeRateLimit(t *testing.T) {
+	address := startTCPServer(t)
+	probeLimiter = NewProbeLimiter(20, 0)
+	t.Cleanup(func() { probeLimiter = nil })
+
+	// 每秒20次，5次连接至少间隔4个50ms
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn, release, _, err := dialProbe("tcp", address)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			conn.Close()
+			release()
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Fatalf("5次连接耗时%s，限流没有生效", elapsed)
+	}
+}
+
+/**
+* 没有配置限流时不等待也不限制并发
+ * @param t
+*/
+func TestProbeLimiterDisabled(t *testing.T) {
+	var limiter *ProbeLimiter
+	limiter.Wait()
+	limiter.AcquireIP("192.0.2.1")()
+
+	limiter = NewProbeLimiter(0, 0)
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		limiter.Wait()
+		limiter.AcquireIP("192.0.2.1")
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("不限流时耗时%s", elapsed)
+	}
+}
@@ -0,0 +1,371 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// 单次探测(连接+TLS握手)的超时时间
+const probeTimeout = 5 * time.Second
+
+// 探测时解析域名使用的函数，测试时替换成固定的解析结果
+var lookupProbeIP = net.DefaultResolver.LookupIP
+
+// 探测失败分类，用于报表统计，分类规则见classifyProbeError
+const (
+	ProbeErrDNSNotFound = "dns_nxdomain"
+	ProbeErrDNS         = "dns_error"
+	ProbeErrRefused     = "connection_refused"
+	ProbeErrTimeout     = "timeout"
+	ProbeErrConnect     = "connect_error"
+	ProbeErrTLSAlert    = "tls_alert"
+	ProbeErrNotTLS      = "not_tls"
+	ProbeErrTLS         = "tls_error"
+	ProbeErrNoCert      = "no_certificate"
+	ProbeErrCertInvalid = "cert_invalid"
+)
+
+// 定义探测错误，Category为失败分类
+type ProbeError struct {
+	Category string
+	Message  string
+	Err      error
+}
+
+// 定义一个目标的探测结果，State是用于检查证书的那次握手，IPs是握手成功的地址
+// NotAfter是所有拿到证书的握手中叶子证书最早的到期时间，IPv4/IPv6或多个IP证书不一致时按它做到期分级
+type probeOutcome struct {
+	State    tls.ConnectionState
+	IPs      []string
+	Issues   []string
+	NotAfter time.Time
+	// State对应那次握手的耗时(TCP连接+TLS握手)，不包含限流等待和重试间隔
+	Latency time.Duration
+	// 其它地址证书链的问题(例如中间证书过期)，合并到State的证书链分析结果中
+	ChainProblems []string
+}
+
+/**
+* 错误信息，格式与原来的"连接异常: xxx"一致
+ * @return string
+*/
+func (e *ProbeError) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+/**
+* 返回原始错误，便于errors.As判断证书过期等具体原因
+ * @return error
+*/
+func (e *ProbeError) Unwrap() error {
+	return e.Err
+}
+
+/**
+* 获取错误的失败分类，不是探测错误时返回空
+ * @param err
+ * @return string
+*/
+func probeErrorCategory(err error) string {
+	var probeErr *ProbeError
+	if errors.As(err, &probeErr) {
+		return probeErr.Category
+	}
+	return ""
+}
+
+/**
+* 与目标建立TCP连接并完成TLS握手，超时、连接被拒绝等临时性失败按probe.retries配置重试，耗时为最后一次连接和握手的耗时
+ * @param network tcp/tcp4/tcp6
+ * @param address
+ * @param serverName
+ * @return tls.ConnectionState
+ * @return string
+ * @return time.Duration
+ * @return error
+*/
+func probeTLS(network string, address string, serverName string) (state tls.ConnectionState, remoteIP string, latency time.Duration, _err error) {
+	retries := viper.GetInt("probe.retries")
+	backoff := time.Duration(viper.GetInt("probe.retry_backoff")) * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		state, remoteIP, latency, _err = probeTLSOnce(network, address, serverName)
+		if _err == nil || attempt >= retries || !isRetryableProbeError(_err) {
+			return state, remoteIP, latency, _err
+		}
+
+		// 每次重试的等待时间翻倍
+		wait := backoff << uint(attempt)
+		infologger.Printf("%s 第%d次探测失败，%s后重试: %v", address, attempt+1, wait, _err)
+		time.Sleep(wait)
+	}
+}
+
+/**
+* 与目标建立TCP连接并完成TLS握手一次，返回连接状态、对端IP和耗时，证书校验失败时返回错误的同时也返回连接状态
+* 耗时只包含TCP连接和TLS握手，不包含限流等待，握手失败时为0
+ * @param network tcp/tcp4/tcp6
+ * @param address
+ * @param serverName
+ * @return tls.ConnectionState
+ * @return string
+ * @return time.Duration
+ * @return error
+*/
+func probeTLSOnce(network string, address string, serverName string) (state tls.ConnectionState, remoteIP string, latency time.Duration, _err error) {
+	// 创建TCP连接探测端口是否通
+	conn, release, connectTime, _err := dialProbe(network, address)
+	if _err != nil {
+		return state, "", 0, &ProbeError{Category: classifyProbeError(_err), Message: "连接异常", Err: _err}
+	}
+	defer release()
+	defer conn.Close()
+
+	if tcpAddr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		remoteIP = tcpAddr.IP.String()
+	}
+
+	// 创建TLS配置并启动TLS握手，握手也需要超时，避免对端不响应时一直阻塞
+	// 握手时不校验证书，握手后再单独校验，这样证书链有问题时也能拿到证书做分析
+	tlsConfig := &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: true,
+	}
+	tlsConn := tls.Client(conn, tlsConfig)
+	tlsConn.SetDeadline(time.Now().Add(probeTimeout))
+	handshakeStart := time.Now()
+	_err = tlsConn.Handshake()
+	if _err != nil {
+		return state, remoteIP, 0, &ProbeError{Category: classifyProbeError(_err), Message: "TLS handshake异常", Err: _err}
+	}
+	latency = connectTime + time.Since(handshakeStart)
+
+	// 获取连接状态并检查证书，校验失败时仍然返回连接状态
+	state = tlsConn.ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		return state, remoteIP, latency, &ProbeError{Category: ProbeErrNoCert, Message: "提取证书异常"}
+	}
+	if _err = verifyPeerCertificates(state.PeerCertificates, serverName); _err != nil {
+		return state, remoteIP, latency, &ProbeError{Category: ProbeErrCertInvalid, Message: "证书校验异常", Err: _err}
+	}
+	return state, remoteIP, latency, nil
+}
+
+/**
+* 记录一次握手拿到的叶子证书到期时间，保留最早的
+ * @param state
+*/
+func (o *probeOutcome) observeExpiry(state tls.ConnectionState) {
+	if len(state.PeerCertificates) == 0 {
+		return
+	}
+	notAfter := state.PeerCertificates[0].NotAfter
+	if o.NotAfter.IsZero() || notAfter.Before(o.NotAfter) {
+		o.NotAfter = notAfter
+	}
+}
+
+/**
+* 由系统选择地址族探测，与原来的探测方式一致
+ * @param host
+ * @param port
+ * @return probeOutcome
+ * @return error
+*/
+func probeDefault(host string, port string) (outcome probeOutcome, _err error) {
+	state, ip, latency, _err := probeTLS("tcp", net.JoinHostPort(host, port), host)
+	outcome.State = state
+	outcome.Latency = latency
+	outcome.observeExpiry(state)
+	if ip != "" {
+		outcome.IPs = []string{ip}
+	}
+	return outcome, _err
+}
+
+/**
+* 分别通过IPv4和IPv6探测，域名没有AAAA记录时只探测IPv4
+* 任意一个地址族握手成功就返回成功，IPv6与IPv4证书不一致、IPv6证书过期或IPv6握手失败时通过Issues返回
+* 两个地址族拿到的证书都参与到期分级(NotAfter取较早的)
+ * @param host
+ * @param port
+ * @return probeOutcome
+ * @return error
+*/
+func probeDualStack(host string, port string) (outcome probeOutcome, _err error) {
+	address := net.JoinHostPort(host, port)
+
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	v4Addrs, _ := lookupProbeIP(ctx, "ip4", host)
+	v6Addrs, _ := lookupProbeIP(ctx, "ip6", host)
+
+	// 没有AAAA记录时与原来的探测方式一致
+	if len(v6Addrs) == 0 {
+		return probeDefault(host, port)
+	}
+
+	v6State, v6IP, v6Latency, v6Err := probeTLS("tcp6", address, host)
+	// 只有AAAA记录的域名
+	if len(v4Addrs) == 0 {
+		outcome = probeOutcome{State: v6State, IPs: []string{v6IP}, Latency: v6Latency}
+		outcome.observeExpiry(v6State)
+		return outcome, v6Err
+	}
+
+	v4State, v4IP, v4Latency, v4Err := probeTLS("tcp4", address, host)
+	switch {
+	case v4Err != nil && v6Err != nil:
+		// 证书校验失败时也拿到了证书，优先使用有证书的那次握手做证书链分析
+		outcome = probeOutcome{State: v4State, Latency: v4Latency}
+		if len(v4State.PeerCertificates) == 0 {
+			outcome.State, outcome.Latency = v6State, v6Latency
+		}
+		_err = v4Err
+	case v4Err != nil:
+		outcome = probeOutcome{State: v6State, IPs: []string{v6IP}, Latency: v6Latency}
+		outcome.Issues = append(outcome.Issues, fmt.Sprintf("IPv4探测失败，IPv6正常: %v", v4Err))
+	case v6Err != nil:
+		outcome = probeOutcome{State: v4State, IPs: []string{v4IP}, Latency: v4Latency}
+		outcome.addFailure("IPv6", v6State, v6Err)
+	default:
+		// 两个地址族都握手成功时比较叶子证书
+		outcome = probeOutcome{State: v4State, IPs: []string{v4IP, v6IP}, Latency: v4Latency}
+		v4Cert := v4State.PeerCertificates[0]
+		v6Cert := v6State.PeerCertificates[0]
+		if !bytes.Equal(v4Cert.Raw, v6Cert.Raw) {
+			outcome.Issues = append(outcome.Issues, fmt.Sprintf("IPv6证书与IPv4不一致(IPv4序列号%s到期%s，IPv6序列号%s到期%s)",
+				v4Cert.SerialNumber.Text(16), v4Cert.NotAfter.Format("2006-01-02"),
+				v6Cert.SerialNumber.Text(16), v6Cert.NotAfter.Format("2006-01-02")))
+		}
+	}
+
+	// 另一个地址族的证书过期或即将过期时也要告警，不能只体现在不一致的提示中
+	outcome.observeExpiry(v4State)
+	outcome.observeExpiry(v6State)
+	return outcome, _err
+}
+
+/**
+* 证书过期导致校验失败时返回对应的证书链问题，叶子证书过期为ChainLeafExpired，中间证书过期为ChainIntermediateExpired，不是过期导致的错误返回空
+ * @param err
+ * @param state
+ * @return string
+*/
+func expiredChainProblem(err error, state tls.ConnectionState) string {
+	cert := expiredCertFromError(err)
+	if cert == nil {
+		return ""
+	}
+	if len(state.PeerCertificates) > 0 && !bytes.Equal(cert.Raw, state.PeerCertificates[0].Raw) {
+		return ChainIntermediateExpired
+	}
+	return ChainLeafExpired
+}
+
+/**
+* 记录某个地址探测失败，只有叶子证书过期才提示证书已过期，中间证书过期作为证书链问题
+ * @param name 地址族或IP
+ * @param state
+ * @param err
+*/
+func (o *probeOutcome) addFailure(name string, state tls.ConnectionState, err error) {
+	switch expiredChainProblem(err, state) {
+	case ChainLeafExpired:
+		o.Issues = append(o.Issues, name+"证书已过期")
+	case ChainIntermediateExpired:
+		o.Issues = append(o.Issues, name+chainProblemText[ChainIntermediateExpired])
+		if !containsFold(o.ChainProblems, ChainIntermediateExpired) {
+			o.ChainProblems = append(o.ChainProblems, ChainIntermediateExpired)
+		}
+	default:
+		o.Issues = append(o.Issues, fmt.Sprintf("%s探测失败: %v", name, err))
+	}
+}
+
+/**
+* 逐个IP进行SNI握手，检查同一域名后面的每个节点(多个SLB/CDN节点)证书是否一致
+* ips为空时通过DNS解析获取全部地址，任意一个IP握手成功就返回成功，其它IP的失败、过期、证书不一致通过Issues返回
+* 所有IP拿到的证书都参与到期分级(NotAfter取最早的)，没有开启IPv6探测并且只有IPv6地址时按域名探测
+ * @param host
+ * @param port
+ * @param ips
+ * @param ipv6 是否探测IPv6地址
+ * @return probeOutcome
+ * @return error
+*/
+func probePerIP(host string, port string, ips []string, ipv6 bool) (outcome probeOutcome, _err error) {
+	if len(ips) == 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+		defer cancel()
+		addrs, _err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if _err != nil {
+			return outcome, &ProbeError{Category: classifyProbeError(_err), Message: "域名解析异常", Err: _err}
+		}
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP.String())
+		}
+	}
+
+	var firstCert *x509.Certificate
+	var firstIP string
+	var failedState tls.ConnectionState
+	var failedLatency time.Duration
+	var probed int
+	for _, ip := range ips {
+		// 没有开启IPv6探测时跳过IPv6地址
+		if parsed := net.ParseIP(ip); parsed != nil && parsed.To4() == nil && !ipv6 {
+			continue
+		}
+
+		probed++
+		ipState, _, latency, err := probeTLS("tcp", net.JoinHostPort(ip, port), host)
+		outcome.observeExpiry(ipState)
+		if err != nil {
+			_err = err
+			// 证书校验失败时保留证书，所有IP都失败时用于证书链分析
+			if len(ipState.PeerCertificates) > 0 && len(failedState.PeerCertificates) == 0 {
+				failedState, failedLatency = ipState, latency
+			}
+			outcome.addFailure(ip, ipState, err)
+			continue
+		}
+
+		cert := ipState.PeerCertificates[0]
+		infologger.Printf("Certificate for %s(%s) expires on: %s\n", host, ip, cert.NotAfter)
+		outcome.IPs = append(outcome.IPs, ip)
+		if firstCert == nil {
+			firstCert, firstIP, outcome.State, outcome.Latency = cert, ip, ipState, latency
+			continue
+		}
+		if !bytes.Equal(firstCert.Raw, cert.Raw) {
+			outcome.Issues = append(outcome.Issues, fmt.Sprintf("%s证书与%s不一致(%s序列号%s到期%s，%s序列号%s到期%s)", ip, firstIP,
+				firstIP, firstCert.SerialNumber.Text(16), firstCert.NotAfter.Format("2006-01-02"),
+				ip, cert.SerialNumber.Text(16), cert.NotAfter.Format("2006-01-02")))
+		}
+	}
+
+	// 只有IPv6地址并且没有开启IPv6探测时，与原来一样由系统选择地址族
+	if probed == 0 {
+		return probeDefault(host, port)
+	}
+	// 所有IP都失败时返回最后一个错误
+	if firstCert == nil {
+		return probeOutcome{State: failedState, NotAfter: outcome.NotAfter, Latency: failedLatency}, fmt.Errorf("所有IP探测失败(%d个): %w", len(outcome.Issues), _err)
+	}
+	return outcome, nil
+}
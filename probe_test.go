@@ -0,0 +1,405 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// 定义测试用的证书和私钥
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+/**
+* 生成测试证书，parent为nil时生成自签证书(根证书)
+ * @param t
+ * @param commonName
+ * @param dnsNames
+ * @param isCA
+ * @param notBefore
+ * @param notAfter
+ * @param parent
+ * @return testCert
+*/
+func newTestCert(t *testing.T, commonName string, dnsNames []string, isCA bool, notBefore time.Time, notAfter time.Time, parent *testCert) testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName},
+		DNSNames:              dnsNames,
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+
+	signerCert, signerKey := template, key
+	if parent != nil {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testCert{cert: cert, key: key}
+}
+
+/**
+* 在address上启动TLS服务，chain为服务端返回的证书链(第一张为叶子证书)
+ * @param t
+ * @param address
+ * @param chain
+ * @return string 实际监听的端口
+*/
+func startTLSServer(t *testing.T, address string, chain ...testCert) string {
+	certificate := tls.Certificate{PrivateKey: chain[0].key, Leaf: chain[0].cert}
+	for _, item := range chain {
+		certificate.Certificate = append(certificate.Certificate, item.cert.Raw)
+	}
+	listener, err := tls.Listen("tcp", address, &tls.Config{Certificates: []tls.Certificate{certificate}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	return port
+}
+
+/**
+* 使用测试根证书校验服务端证书
+ * @param t
+ * @param root
+*/
+func trustTestRoot(t *testing.T, root testCert) {
+	pool := x509.NewCertPool()
+	pool.AddCert(root.cert)
+	previous := probeRootCAs
+	probeRootCAs = pool
+	t.Cleanup(func() { probeRootCAs = previous })
+}
+
+/**
+* 逐IP探测时，非第一个IP的证书不一致、即将到期或已过期也要按最早的到期时间分级
+ * @param t
+*/
+func TestProbePerIPEarliestExpiry(t *testing.T) {
+	now := time.Now()
+	root := newTestCert(t, "Test Root", nil, true, now.Add(-time.Hour), now.Add(10*365*24*time.Hour), nil)
+	trustTestRoot(t, root)
+
+	host := "www.example.com"
+	valid := newTestCert(t, host, []string{host}, false, now.Add(-time.Hour), now.Add(90*24*time.Hour), &root)
+	expiring := newTestCert(t, host, []string{host}, false, now.Add(-time.Hour), now.Add(5*24*time.Hour), &root)
+	expired := newTestCert(t, host, []string{host}, false, now.Add(-48*time.Hour), now.Add(-24*time.Hour), &root)
+
+	// 127.0.0.1和127.0.0.2使用同一个端口，模拟同一个域名后面的两个节点
+	port := startTLSServer(t, "127.0.0.1:0", valid)
+	startTLSServer(t, "127.0.0.2:"+port, expiring)
+
+	outcome, err := probePerIP(host, port, []string{"127.0.0.1", "127.0.0.2"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outcome.IPs) != 2 {
+		t.Fatalf("IPs = %v, want 2个", outcome.IPs)
+	}
+	if !outcome.NotAfter.Equal(expiring.cert.NotAfter) {
+		t.Fatalf("NotAfter = %s, want %s", outcome.NotAfter, expiring.cert.NotAfter)
+	}
+	if len(outcome.Issues) != 1 || !strings.Contains(outcome.Issues[0], "不一致") {
+		t.Fatalf("Issues = %v, want 证书不一致", outcome.Issues)
+	}
+	if level := classifyExpiry(host, outcome.NotAfter, now, 30, 7).Level; level != CertLevelCritical {
+		t.Fatalf("到期分级 = %s, want %s", level, CertLevelCritical)
+	}
+
+	// 第二个节点证书已过期时握手校验失败，到期时间仍然参与分级
+	port = startTLSServer(t, "127.0.0.1:0", valid)
+	startTLSServer(t, "127.0.0.2:"+port, expired)
+	outcome, err = probePerIP(host, port, []string{"127.0.0.1", "127.0.0.2"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !outcome.NotAfter.Equal(expired.cert.NotAfter) {
+		t.Fatalf("NotAfter = %s, want %s", outcome.NotAfter, expired.cert.NotAfter)
+	}
+	if len(outcome.Issues) != 1 || outcome.Issues[0] != "127.0.0.2证书已过期" {
+		t.Fatalf("Issues = %v, want [127.0.0.2证书已过期]", outcome.Issues)
+	}
+}
+
+/**
+* 中间证书过期不是证书过期，作为证书链问题返回，到期分级仍然使用叶子证书的到期时间
+ * @param t
+*/
+func TestProbePerIPIntermediateExpired(t *testing.T) {
+	now := time.Now()
+	root := newTestCert(t, "Test Root", nil, true, now.Add(-time.Hour), now.Add(10*365*24*time.Hour), nil)
+	trustTestRoot(t, root)
+
+	host := "www.example.com"
+	intermediate := newTestCert(t, "Test Intermediate", nil, true, now.Add(-48*time.Hour), now.Add(-24*time.Hour), &root)
+	leaf := newTestCert(t, host, []string{host}, false, now.Add(-48*time.Hour), now.Add(90*24*time.Hour), &intermediate)
+	valid := newTestCert(t, host, []string{host}, false, now.Add(-time.Hour), now.Add(60*24*time.Hour), &root)
+
+	port := startTLSServer(t, "127.0.0.1:0", valid)
+	startTLSServer(t, "127.0.0.2:"+port, leaf, intermediate)
+
+	outcome, err := probePerIP(host, port, []string{"127.0.0.1", "127.0.0.2"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outcome.Issues) != 1 || outcome.Issues[0] != "127.0.0.2中间证书已过期" {
+		t.Fatalf("Issues = %v, want [127.0.0.2中间证书已过期]", outcome.Issues)
+	}
+	if len(outcome.ChainProblems) != 1 || outcome.ChainProblems[0] != ChainIntermediateExpired {
+		t.Fatalf("ChainProblems = %v, want [%s]", outcome.ChainProblems, ChainIntermediateExpired)
+	}
+	if !outcome.NotAfter.Equal(valid.cert.NotAfter) {
+		t.Fatalf("NotAfter = %s, want %s", outcome.NotAfter, valid.cert.NotAfter)
+	}
+
+	// 只有这一个节点时握手失败，拿到的证书按叶子证书分级，证书链分析报告中间证书过期
+	port = startTLSServer(t, "127.0.0.1:0", leaf, intermediate)
+	outcome, err = probePerIP(host, port, []string{"127.0.0.1"}, false)
+	if err == nil {
+		t.Fatal("中间证书过期时没有返回校验错误")
+	}
+	if problem := expiredChainProblem(err, outcome.State); problem != ChainIntermediateExpired {
+		t.Fatalf("expiredChainProblem = %s, want %s", problem, ChainIntermediateExpired)
+	}
+	if level := classifyExpiry(host, outcome.NotAfter, now, 30, 7).Level; level != CertLevelOK {
+		t.Fatalf("到期分级 = %s, want %s", level, CertLevelOK)
+	}
+	report := analyzeChain(host, host, outcome.State.PeerCertificates, now)
+	if !containsFold(report.Problems, ChainIntermediateExpired) || containsFold(report.Problems, ChainLeafExpired) {
+		t.Fatalf("证书链问题 = %v, want [%s]", report.Problems, ChainIntermediateExpired)
+	}
+}
+
+/**
+* 只有IPv6地址并且没有开启IPv6探测时按域名探测，不能当作探测失败
+ * @param t
+*/
+func TestProbePerIPIPv6OnlyFallback(t *testing.T) {
+	now := time.Now()
+	root := newTestCert(t, "Test Root", nil, true, now.Add(-time.Hour), now.Add(10*365*24*time.Hour), nil)
+	trustTestRoot(t, root)
+
+	leaf := newTestCert(t, "localhost", []string{"localhost"}, false, now.Add(-time.Hour), now.Add(90*24*time.Hour), &root)
+	port := startTLSServer(t, "127.0.0.1:0", leaf)
+
+	outcome, err := probePerIP("localhost", port, []string{"2001:db8::1"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outcome.State.PeerCertificates) == 0 || !outcome.NotAfter.Equal(leaf.cert.NotAfter) {
+		t.Fatalf("没有按域名探测: %+v", outcome)
+	}
+}
+
+/**
+* 耗时只统计连接和握手，限流等待只算进整个探测的耗时
+ * @param t
+*/
+func TestProbeLatencyExcludesRateLimit(t *testing.T) {
+	now := time.Now()
+	root := newTestCert(t, "Test Root", nil, true, now.Add(-time.Hour), now.Add(10*365*24*time.Hour), nil)
+	trustTestRoot(t, root)
+	leaf := newTestCert(t, "localhost", []string{"localhost"}, false, now.Add(-time.Hour), now.Add(90*24*time.Hour), &root)
+	port := startTLSServer(t, "127.0.0.1:0", leaf)
+
+	// 每秒2次握手，第二次探测要在限流器中等待约500毫秒
+	probeLimiter = NewProbeLimiter(2, 1)
+	t.Cleanup(func() { probeLimiter = nil })
+	if _, err := probeDefault("localhost", port); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	outcome, err := probeDefault("localhost", port)
+	duration := time.Since(start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if duration < 400*time.Millisecond {
+		t.Fatalf("探测耗时%s，限流器没有生效", duration)
+	}
+	if outcome.Latency <= 0 || outcome.Latency >= 200*time.Millisecond {
+		t.Fatalf("Latency = %s，不应包含限流等待", outcome.Latency)
+	}
+
+	result := newProbeResult("localhost:"+port, "localhost", port, outcome, nil, duration, start)
+	if result.LatencyMs != outcome.Latency.Milliseconds() || result.DurationMs != duration.Milliseconds() {
+		t.Fatalf("LatencyMs = %d, DurationMs = %d", result.LatencyMs, result.DurationMs)
+	}
+}
+
+/**
+* 把host固定解析到IPv4和IPv6回环地址，其它域名返回不存在
+ * @param t
+ * @param host
+*/
+func resolveDualStackLoopback(t *testing.T, host string) {
+	listener, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		t.Skipf("不支持IPv6回环地址: %v", err)
+	}
+	listener.Close()
+
+	previous := lookupProbeIP
+	lookupProbeIP = func(ctx context.Context, network string, name string) ([]net.IP, error) {
+		if name != host {
+			return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+		}
+		switch network {
+		case "ip4":
+			return []net.IP{net.ParseIP("127.0.0.1")}, nil
+		case "ip6":
+			return []net.IP{net.ParseIP("::1")}, nil
+		}
+		return []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")}, nil
+	}
+	t.Cleanup(func() { lookupProbeIP = previous })
+}
+
+/**
+* IPv4和IPv6分别握手，IPv6证书不一致时提示不一致，并按较早的到期时间分级
+ * @param t
+*/
+func TestProbeDualStackMismatch(t *testing.T) {
+	host := "www.example.com"
+	resolveDualStackLoopback(t, host)
+
+	now := time.Now()
+	root := newTestCert(t, "Test Root", nil, true, now.Add(-time.Hour), now.Add(10*365*24*time.Hour), nil)
+	trustTestRoot(t, root)
+	v4Leaf := newTestCert(t, host, []string{host}, false, now.Add(-time.Hour), now.Add(90*24*time.Hour), &root)
+	v6Leaf := newTestCert(t, host, []string{host}, false, now.Add(-time.Hour), now.Add(5*24*time.Hour), &root)
+
+	port := startTLSServer(t, "127.0.0.1:0", v4Leaf)
+	startTLSServer(t, "[::1]:"+port, v6Leaf)
+
+	outcome, err := probeDualStack(host, port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outcome.IPs) != 2 || outcome.IPs[0] != "127.0.0.1" || outcome.IPs[1] != "::1" {
+		t.Fatalf("IPs = %v, want [127.0.0.1 ::1]", outcome.IPs)
+	}
+	if !bytes.Equal(outcome.State.PeerCertificates[0].Raw, v4Leaf.cert.Raw) {
+		t.Fatal("State不是IPv4握手拿到的证书")
+	}
+	if len(outcome.Issues) != 1 || !strings.Contains(outcome.Issues[0], "IPv6证书与IPv4不一致") {
+		t.Fatalf("Issues = %v, want IPv6证书与IPv4不一致", outcome.Issues)
+	}
+	if !outcome.NotAfter.Equal(v6Leaf.cert.NotAfter) {
+		t.Fatalf("NotAfter = %s, want %s", outcome.NotAfter, v6Leaf.cert.NotAfter)
+	}
+}
+
+/**
+* IPv6证书过期时IPv4探测仍然成功，过期写入probeIssueSlice并按IPv6的到期时间告警
+ * @param t
+*/
+func TestExpirationHttpsDomainIPv6Expired(t *testing.T) {
+	host := "www.example.com"
+	resolveDualStackLoopback(t, host)
+
+	now := time.Now()
+	root := newTestCert(t, "Test Root", nil, true, now.Add(-time.Hour), now.Add(10*365*24*time.Hour), nil)
+	v4Leaf := newTestCert(t, host, []string{host}, false, now.Add(-time.Hour), now.Add(90*24*time.Hour), &root)
+	v6Leaf := newTestCert(t, host, []string{host}, false, now.Add(-48*time.Hour), now.Add(-24*time.Hour), &root)
+	port := startTLSServer(t, "127.0.0.1:0", v4Leaf)
+	startTLSServer(t, "[::1]:"+port, v6Leaf)
+
+	workDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.Chdir(workDir)
+		viper.Reset()
+		probeLimiter = nil
+		probeRootCAs = nil
+	})
+
+	caFile := filepath.Join(dir, "ca.pem")
+	if err := ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.cert.Raw}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	target := net.JoinHostPort(host, port)
+	if err := ioutil.WriteFile("domains.txt", []byte(target+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	viper.Reset()
+	viper.Set("probe.ipv6", true)
+	viper.Set("probe.ca_file", caFile)
+	viper.Set("probe.workers", 1)
+	viper.Set("expiry.warning_days", 30)
+	viper.Set("expiry.critical_days", 7)
+
+	if err := ExpirationHttpsDomain(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{target + ": IPv6证书已过期"}; !reflect.DeepEqual(probeIssueSlice, want) {
+		t.Fatalf("probeIssueSlice = %q, want %q", probeIssueSlice, want)
+	}
+	if len(expirySlice) != 1 || expirySlice[0].Level != CertLevelExpired {
+		t.Fatalf("expirySlice = %+v, want IPv6证书已过期", expirySlice)
+	}
+	if httpsDomainSum != 1 {
+		t.Fatalf("httpsDomainSum = %d, want 1", httpsDomainSum)
+	}
+}
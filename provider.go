@@ -0,0 +1,209 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+// 定义DNS服务商配置，对应config.yml中providers列表的每一项，除name/type/title外的配置项都放到Options中由各服务商自行读取
+type ProviderConfig struct {
+	Name    string                 `mapstructure:"name"`
+	Type    string                 `mapstructure:"type"`
+	Title   string                 `mapstructure:"title"`
+	Options map[string]interface{} `mapstructure:",remain"`
+}
+
+// 定义域名解析记录，各服务商返回的记录都统一转换成该结构体
+type DomainRecord struct {
+	Provider string
+	Zone     string
+	RR       string
+	Type     string
+	Value    string
+	Status   string
+	Remark   string
+	// Route 53别名记录(指向ELB/CloudFront等)，记录值为别名目标而不是IP
+	Alias bool
+	// 端口和标签目前只有静态清单会设置，端口为0表示443
+	Port   int
+	Labels map[string]string
+	// 同一域名所有A/AAAA记录的IP，加入域名清单时汇总
+	IPs []string
+}
+
+// 定义DNS服务商接口，新增服务商只需要实现该接口并在providerFactories中注册
+type DNSProvider interface {
+	// 服务商名称，与配置中的name一致
+	Name() string
+	// 查询域名列表
+	DescribeDomains() ([]string, error)
+	// 查询某个域名下的解析记录
+	DescribeDomainRecords(domain string) ([]DomainRecord, error)
+}
+
+// 定义服务商构造函数，根据配置初始化SDK并返回DNSProvider
+type providerFactory func(conf ProviderConfig) (DNSProvider, error)
+
+// 服务商类型与构造函数的对应关系，key对应配置中的type
+var providerFactories = map[string]providerFactory{
+	"aliyun":     NewAliyunProvider,
+	"tencent":    NewTencentProvider,
+	"cloudflare": NewCloudflareProvider,
+	"route53":    NewRoute53Provider,
+	"huawei":     NewHuaweiProvider,
+	"bind":       NewBindProvider,
+	"static":     NewStaticProvider,
+}
+
+/**
+* 读取服务商配置项(字符串)
+ * @param key
+ * @return string
+*/
+func (c ProviderConfig) GetString(key string) string {
+	return cast.ToString(c.Options[key])
+}
+
+/**
+* 解析记录对应的完整域名
+ * @return string
+*/
+func (r DomainRecord) Host() string {
+	// 静态清单的记录没有zone，RR就是完整域名
+	if r.Zone == "" {
+		return r.RR
+	}
+	// @表示zone本身(主域名)
+	if r.RR == "@" || r.RR == "" {
+		return r.Zone
+	}
+	return r.RR + "." + r.Zone
+}
+
+/**
+* 探测目标，非443端口时带上端口
+ * @return string
+*/
+func (r DomainRecord) Target() string {
+	if r.Port == 0 || r.Port == 443 {
+		return r.Host()
+	}
+	return net.JoinHostPort(r.Host(), strconv.Itoa(r.Port))
+}
+
+/**
+* 读取服务商配置项(字符串列表)
+ * @param key
+ * @return []string
+*/
+func (c ProviderConfig) GetStringSlice(key string) []string {
+	return cast.ToStringSlice(c.Options[key])
+}
+
+/**
+* 字符串指针取值，nil返回空字符串
+ * @param s
+ * @return string
+*/
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+/**
+* 把完整域名转换成相对于zone的主机记录，zone本身返回@
+ * @param fqdn
+ * @param zone
+ * @return string
+*/
+func relativeName(fqdn string, zone string) string {
+	fqdn = strings.TrimSuffix(strings.ToLower(fqdn), ".")
+	zone = strings.TrimSuffix(strings.ToLower(zone), ".")
+	if fqdn == zone {
+		return "@"
+	}
+	return strings.TrimSuffix(fqdn, "."+zone)
+}
+
+// 旧版配置cloud下的服务商与providers的对应关系
+var legacyProviderConfigs = []ProviderConfig{
+	{Name: "aliyun", Type: "aliyun", Title: "阿里云"},
+	{Name: "tencent", Type: "tencent", Title: "腾讯云"},
+}
+
+// 旧版配置的key，与legacyProviderConfigs按顺序对应
+var legacyProviderKeys = []string{"cloud.alibaba", "cloud.tencent"}
+
+/**
+* 读取config.yml中的providers配置，没有providers时兼容旧版的cloud.alibaba和cloud.tencent配置
+ * @return []ProviderConfig
+ * @return error
+*/
+func LoadProviderConfigs() (confs []ProviderConfig, _err error) {
+	if viper.IsSet("providers") {
+		_err = viper.UnmarshalKey("providers", &confs)
+		if _err != nil {
+			return nil, _err
+		}
+	} else {
+		for i, key := range legacyProviderKeys {
+			if !viper.IsSet(key) {
+				continue
+			}
+			conf := legacyProviderConfigs[i]
+			conf.Options = viper.GetStringMap(key)
+			confs = append(confs, conf)
+		}
+	}
+
+	// 没有任何服务商时直接报错，避免生成空的域名清单和targets文件后Reload，清空已有的监控
+	if len(confs) == 0 {
+		return nil, fmt.Errorf("没有配置DNS服务商，请在providers中至少配置一项")
+	}
+
+	// name用于区分各服务商的域名列表和执行状态，不能重复
+	names := make(map[string]int)
+	for i := range confs {
+		if confs[i].Name == "" {
+			return nil, fmt.Errorf("providers第%d项缺少name配置", i+1)
+		}
+		if first, ok := names[confs[i].Name]; ok {
+			return nil, fmt.Errorf("providers第%d项的name %s与第%d项重复", i+1, confs[i].Name, first)
+		}
+		names[confs[i].Name] = i + 1
+		// 没有配置type时默认与name一致
+		if confs[i].Type == "" {
+			confs[i].Type = confs[i].Name
+		}
+		// 没有配置title时通知中显示name
+		if confs[i].Title == "" {
+			confs[i].Title = confs[i].Name
+		}
+	}
+	return confs, nil
+}
+
+/**
+* 根据配置创建DNS服务商
+ * @param conf
+ * @return DNSProvider
+ * @return error
+*/
+func NewDNSProvider(conf ProviderConfig) (provider DNSProvider, _err error) {
+	factory, ok := providerFactories[conf.Type]
+	if !ok {
+		return nil, fmt.Errorf("不支持的DNS服务商类型: %s", conf.Type)
+	}
+	return factory(conf)
+}
@@ -0,0 +1,140 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	alidns "github.com/alibabacloud-go/alidns-20150109/v2/client"
+	aliopenapi "github.com/alibabacloud-go/darabonba-openapi/client"
+	"github.com/alibabacloud-go/tea/tea"
+)
+
+// 定义阿里云DNS服务商
+type AliyunProvider struct {
+	name   string
+	client *alidns.Client
+}
+
+/**
+* 初始化阿里云SDK
+ * @param accessKeyId
+ * @param accessKeySecret
+ * @return *alidns.Client
+ * @return error
+*/
+func AliyunInit(accessKeyId *string, accessKeySecret *string, regionId *string) (alidnsClient *alidns.Client, _err error) {
+
+	// &号表示创建了一个aliopenapi.Config类型的零值实例，并获取了这个实例的内存地址。这个地址被赋值给了config变量。因此config是一个指向aliopenapi.Config类型值的指针。
+	config := &aliopenapi.Config{}
+	config.AccessKeyId = accessKeyId
+	config.AccessKeySecret = accessKeySecret
+	config.RegionId = regionId
+
+	// _result是一个指向alidns.Client类型的指针
+	ailClient, _err := alidns.NewClient(config)
+	return ailClient, _err
+}
+
+/**
+* 根据配置创建阿里云DNS服务商
+ * @param conf
+ * @return DNSProvider
+ * @return error
+*/
+func NewAliyunProvider(conf ProviderConfig) (provider DNSProvider, _err error) {
+	// 阿里云密钥
+	accessKeyId := conf.GetString("aliyun_key")
+	accessKeySecret := conf.GetString("aliyun_secret")
+	regionId := conf.GetString("region")
+
+	client, _err := AliyunInit(&accessKeyId, &accessKeySecret, &regionId)
+	if _err != nil {
+		return nil, _err
+	}
+	return &AliyunProvider{name: conf.Name, client: client}, nil
+}
+
+/**
+* 服务商名称
+ * @return string
+*/
+func (p *AliyunProvider) Name() string {
+	return p.name
+}
+
+/**
+* 查询阿里云域名列表
+ * @return []string
+ * @return error
+*/
+func (p *AliyunProvider) DescribeDomains() (domains []string, _err error) {
+	// 定义初始页码和每页大小
+	pageNumber := 1
+	pageSize := 20
+
+	// for循环主要是循环每页
+	for {
+		// 创建一个指向dns.DescribeDomainsRequest类型结构体的指针，并初始化其成员变量PageNumber和PageSize
+		req := &alidns.DescribeDomainsRequest{
+			PageNumber: tea.Int64(int64(pageNumber)),
+			PageSize:   tea.Int64(int64(pageSize)),
+		}
+
+		resp, _err := p.client.DescribeDomains(req)
+		if _err != nil {
+			return nil, _err
+		}
+
+		if len(resp.Body.Domains.Domain) == 0 {
+			return domains, nil
+		}
+		for _, domain := range resp.Body.Domains.Domain {
+			domains = append(domains, *domain.DomainName)
+		}
+		// 更新页码以获取下一页
+		pageNumber++
+	}
+}
+
+/**
+* 查询阿里云域名解析记录
+ * @param domainName
+ * @return []DomainRecord
+ * @return error
+*/
+func (p *AliyunProvider) DescribeDomainRecords(domainName string) (records []DomainRecord, _err error) {
+	// 定义初始页码和每页大小
+	pageNumber := 1
+	pageSize := 20
+
+	for {
+		req := &alidns.DescribeDomainRecordsRequest{
+			PageNumber: tea.Int64(int64(pageNumber)),
+			PageSize:   tea.Int64(int64(pageSize)),
+		}
+		req.DomainName = tea.String(domainName)
+
+		resp, _err := p.client.DescribeDomainRecords(req)
+		if _err != nil {
+			return nil, _err
+		}
+
+		if len(resp.Body.DomainRecords.Record) == 0 {
+			return records, nil
+		}
+		for _, record := range resp.Body.DomainRecords.Record {
+			records = append(records, DomainRecord{
+				Provider: p.name,
+				Zone:     domainName,
+				RR:       tea.StringValue(record.RR),
+				Type:     tea.StringValue(record.Type),
+				Value:    tea.StringValue(record.Value),
+				Status:   tea.StringValue(record.Status),
+				Remark:   tea.StringValue(record.Remark),
+			})
+		}
+		// 更新页码以获取下一页
+		pageNumber++
+	}
+}
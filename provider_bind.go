@@ -0,0 +1,267 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// 定义自建DNS(BIND/PowerDNS)服务商，支持读取本地zone文件和通过AXFR从主服务器拉取zone
+type BindProvider struct {
+	name       string
+	zoneFiles  []string
+	zoneDir    string
+	axfrServer string
+	axfrZones  []string
+	tsigName   string
+	tsigSecret string
+	tsigAlgo   string
+	timeout    time.Duration
+	// zone文件解析结果，每次DescribeDomains时重新生成
+	fileRecords map[string][]dns.RR
+}
+
+/**
+* 根据配置创建自建DNS服务商
+ * @param conf
+ * @return DNSProvider
+ * @return error
+*/
+func NewBindProvider(conf ProviderConfig) (provider DNSProvider, _err error) {
+	p := &BindProvider{
+		name:        conf.Name,
+		zoneFiles:   conf.GetStringSlice("zone_files"),
+		zoneDir:     conf.GetString("zone_dir"),
+		axfrServer:  conf.GetString("axfr_server"),
+		axfrZones:   conf.GetStringSlice("axfr_zones"),
+		tsigName:    conf.GetString("tsig_name"),
+		tsigSecret:  conf.GetString("tsig_secret"),
+		tsigAlgo:    conf.GetString("tsig_algorithm"),
+		timeout:     30 * time.Second,
+		fileRecords: make(map[string][]dns.RR),
+	}
+
+	if len(p.axfrZones) > 0 && p.axfrServer == "" {
+		return nil, fmt.Errorf("%s配置了axfr_zones但缺少axfr_server", conf.Name)
+	}
+	// 主服务器没有写端口时使用53
+	if p.axfrServer != "" {
+		if _, _, err := net.SplitHostPort(p.axfrServer); err != nil {
+			p.axfrServer = p.axfrServer + ":53"
+		}
+	}
+	if p.tsigAlgo == "" {
+		p.tsigAlgo = dns.HmacSHA256
+	}
+	return p, nil
+}
+
+/**
+* 服务商名称
+ * @return string
+*/
+func (p *BindProvider) Name() string {
+	return p.name
+}
+
+/**
+* 获取需要解析的zone文件，zone_dir下的所有文件都当作zone文件，新增的文件在下一次同步时生效
+ * @return []string
+ * @return error
+*/
+func (p *BindProvider) listZoneFiles() (zoneFiles []string, _err error) {
+	zoneFiles = append(zoneFiles, p.zoneFiles...)
+	if p.zoneDir == "" {
+		return zoneFiles, nil
+	}
+
+	entries, _err := ioutil.ReadDir(p.zoneDir)
+	if _err != nil {
+		return nil, _err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		zoneFiles = append(zoneFiles, filepath.Join(p.zoneDir, entry.Name()))
+	}
+	return zoneFiles, nil
+}
+
+/**
+* 查询域名列表，包括zone文件中的域名和需要AXFR的域名
+ * @return []string
+ * @return error
+*/
+func (p *BindProvider) DescribeDomains() (domains []string, _err error) {
+	// 常驻进程中会重复调用，每次重新扫描zone_dir并解析zone文件，全部解析成功后再替换上一次的结果
+	zoneFiles, _err := p.listZoneFiles()
+	if _err != nil {
+		return nil, _err
+	}
+	fileRecords := make(map[string][]dns.RR)
+	for _, zoneFile := range zoneFiles {
+		zone, rrs, _err := parseZoneFile(zoneFile)
+		if _err != nil {
+			return nil, _err
+		}
+		if _, ok := fileRecords[zone]; !ok {
+			domains = append(domains, zone)
+		}
+		fileRecords[zone] = append(fileRecords[zone], rrs...)
+	}
+
+	for _, zone := range p.axfrZones {
+		zone = strings.TrimSuffix(strings.ToLower(zone), ".")
+		if _, ok := fileRecords[zone]; ok {
+			errlogger.Printf("%s同时配置了zone文件和AXFR，使用zone文件", zone)
+			continue
+		}
+		domains = append(domains, zone)
+	}
+	p.fileRecords = fileRecords
+	return domains, nil
+}
+
+/**
+* 查询域名解析记录，zone文件中没有的域名通过AXFR拉取
+ * @param domainName
+ * @return []DomainRecord
+ * @return error
+*/
+func (p *BindProvider) DescribeDomainRecords(domainName string) (records []DomainRecord, _err error) {
+	rrs, ok := p.fileRecords[domainName]
+	if !ok {
+		rrs, _err = p.transferZone(domainName)
+		if _err != nil {
+			return nil, _err
+		}
+	}
+
+	for _, rr := range rrs {
+		header := rr.Header()
+		records = append(records, DomainRecord{
+			Provider: p.name,
+			Zone:     domainName,
+			RR:       relativeName(header.Name, domainName),
+			Type:     dns.TypeToString[header.Rrtype],
+			Value:    rrValue(rr),
+			// zone文件中的记录都是生效的
+			Status: "ENABLE",
+		})
+	}
+	return records, nil
+}
+
+/**
+* 通过AXFR从主服务器拉取zone，配置了tsig_name时使用TSIG签名
+ * @param zone
+ * @return []dns.RR
+ * @return error
+*/
+func (p *BindProvider) transferZone(zone string) (rrs []dns.RR, _err error) {
+	msg := new(dns.Msg)
+	msg.SetAxfr(dns.Fqdn(zone))
+
+	transfer := &dns.Transfer{
+		DialTimeout:  p.timeout,
+		ReadTimeout:  p.timeout,
+		WriteTimeout: p.timeout,
+	}
+	if p.tsigName != "" {
+		tsigName := dns.Fqdn(p.tsigName)
+		msg.SetTsig(tsigName, dns.Fqdn(p.tsigAlgo), 300, time.Now().Unix())
+		transfer.TsigSecret = map[string]string{tsigName: p.tsigSecret}
+	}
+
+	envelopes, _err := transfer.In(msg, p.axfrServer)
+	if _err != nil {
+		return nil, _err
+	}
+	for envelope := range envelopes {
+		if envelope.Error != nil {
+			return nil, fmt.Errorf("AXFR %s from %s: %v", zone, p.axfrServer, envelope.Error)
+		}
+		for _, rr := range envelope.RR {
+			// AXFR首尾都会返回SOA记录，去掉结尾重复的那条
+			if rr.Header().Rrtype == dns.TypeSOA && len(rrs) > 0 {
+				continue
+			}
+			rrs = append(rrs, rr)
+		}
+	}
+	return rrs, nil
+}
+
+/**
+* 解析RFC 1035格式的zone文件，zone名称优先取SOA记录，没有SOA时根据文件名推断(db.example.com或example.com.zone)
+ * @param zoneFile
+ * @return string
+ * @return []dns.RR
+ * @return error
+*/
+func parseZoneFile(zoneFile string) (zone string, rrs []dns.RR, _err error) {
+	file, _err := os.Open(zoneFile)
+	if _err != nil {
+		return "", nil, _err
+	}
+	defer file.Close()
+
+	// 文件中有$ORIGIN时解析器会覆盖这里的默认值
+	origin := zoneNameFromFile(zoneFile)
+	parser := dns.NewZoneParser(file, dns.Fqdn(origin), zoneFile)
+	for rr, ok := parser.Next(); ok; rr, ok = parser.Next() {
+		if soa, isSOA := rr.(*dns.SOA); isSOA && zone == "" {
+			zone = soa.Hdr.Name
+		}
+		rrs = append(rrs, rr)
+	}
+	if _err = parser.Err(); _err != nil {
+		return "", nil, _err
+	}
+
+	if zone == "" {
+		zone = origin
+	}
+	return strings.TrimSuffix(strings.ToLower(zone), "."), rrs, nil
+}
+
+/**
+* 根据zone文件名推断zone名称
+ * @param zoneFile
+ * @return string
+*/
+func zoneNameFromFile(zoneFile string) string {
+	name := filepath.Base(zoneFile)
+	name = strings.TrimPrefix(name, "db.")
+	name = strings.TrimSuffix(name, ".zone")
+	name = strings.TrimSuffix(name, ".db")
+	return name
+}
+
+/**
+* 获取资源记录的值(去掉名称、TTL、类型等头部信息)
+ * @param rr
+ * @return string
+*/
+func rrValue(rr dns.RR) string {
+	switch v := rr.(type) {
+	case *dns.A:
+		return v.A.String()
+	case *dns.AAAA:
+		return v.AAAA.String()
+	case *dns.CNAME:
+		return strings.TrimSuffix(v.Target, ".")
+	}
+	return strings.TrimSpace(strings.TrimPrefix(rr.String(), rr.Header().String()))
+}
@@ -0,0 +1,262 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const testZoneFile = `$ORIGIN example.com.
+$TTL 600
+@	IN	SOA	ns1.example.com. admin.example.com. 1 3600 600 86400 600
+@	IN	A	192.0.2.1
+www	IN	CNAME	example.com.
+`
+
+/**
+* 常驻进程中DescribeDomains会被重复调用，每次都要返回zone文件中的域名，记录不能重复累加
+ * @param t
+*/
+func TestBindProviderDescribeDomainsRepeated(t *testing.T) {
+	zoneFile := filepath.Join(t.TempDir(), "db.example.com")
+	if err := ioutil.WriteFile(zoneFile, []byte(testZoneFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	provider, err := NewBindProvider(ProviderConfig{Name: "bind", Type: "bind", Options: map[string]interface{}{"zone_files": []string{zoneFile}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		domains, err := provider.DescribeDomains()
+		if err != nil {
+			t.Fatalf("第%d次DescribeDomains: %v", i, err)
+		}
+		if len(domains) != 1 || domains[0] != "example.com" {
+			t.Fatalf("第%d次DescribeDomains = %v, want [example.com]", i, domains)
+		}
+		records, err := provider.DescribeDomainRecords("example.com")
+		if err != nil {
+			t.Fatalf("第%d次DescribeDomainRecords: %v", i, err)
+		}
+		if len(records) != 3 {
+			t.Fatalf("第%d次DescribeDomainRecords返回%d条记录, want 3", i, len(records))
+		}
+	}
+
+	// zone文件中删除的记录在下一次同步时不再返回
+	if err := ioutil.WriteFile(zoneFile, []byte(testZoneFile[:len(testZoneFile)-len("www\tIN\tCNAME\texample.com.\n")]), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.DescribeDomains(); err != nil {
+		t.Fatal(err)
+	}
+	records, err := provider.DescribeDomainRecords("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("删除记录后DescribeDomainRecords返回%d条记录, want 2", len(records))
+	}
+}
+
+/**
+* zone_dir在每次DescribeDomains时重新扫描，启动后新增的zone文件在下一次同步时生效
+ * @param t
+*/
+func TestBindProviderZoneDirRescan(t *testing.T) {
+	zoneDir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(zoneDir, "db.example.com"), []byte(testZoneFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	provider, err := NewBindProvider(ProviderConfig{Name: "bind", Type: "bind", Options: map[string]interface{}{"zone_dir": zoneDir}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	domains, err := provider.DescribeDomains()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(domains) != 1 || domains[0] != "example.com" {
+		t.Fatalf("DescribeDomains = %v, want [example.com]", domains)
+	}
+
+	// 启动后新增zone文件，隐藏文件(编辑器的临时文件等)不当作zone文件
+	newZone := "$ORIGIN example.org.\n$TTL 600\n@\tIN\tSOA\tns1.example.org. admin.example.org. 1 3600 600 86400 600\nwww\tIN\tA\t192.0.2.2\n"
+	if err := ioutil.WriteFile(filepath.Join(zoneDir, "db.example.org"), []byte(newZone), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(zoneDir, ".db.example.org.swp"), []byte("not a zone"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	domains, err = provider.DescribeDomains()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(domains) != 2 || domains[1] != "example.org" {
+		t.Fatalf("新增zone文件后DescribeDomains = %v, want [example.com example.org]", domains)
+	}
+	records, err := provider.DescribeDomainRecords("example.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[1].Host() != "www.example.org" {
+		t.Fatalf("DescribeDomainRecords = %+v, want SOA和www.example.org", records)
+	}
+}
+
+// 测试用TSIG密钥
+const (
+	testTsigName   = "axfr-key."
+	testTsigSecret = "c2VjcmV0LWtleS1mb3ItYXhmci10ZXN0cw=="
+)
+
+/**
+* 启动本地AXFR服务，只允许带有效TSIG签名的example.com传送，refused.example.com拒绝传送
+ * @param t
+ * @return string
+*/
+func startAXFRServer(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		reply := new(dns.Msg)
+		reply.SetReply(r)
+		// 没有签名或签名校验失败时拒绝
+		if r.IsTsig() == nil || w.TsigStatus() != nil {
+			reply.Rcode = dns.RcodeNotAuth
+			w.WriteMsg(reply)
+			return
+		}
+		if r.Question[0].Name != "example.com." {
+			reply.Rcode = dns.RcodeRefused
+			w.WriteMsg(reply)
+			return
+		}
+
+		soa, _ := dns.NewRR("example.com. 600 IN SOA ns1.example.com. admin.example.com. 1 3600 600 86400 600")
+		a, _ := dns.NewRR("example.com. 600 IN A 192.0.2.1")
+		cname, _ := dns.NewRR("www.example.com. 600 IN CNAME example.com.")
+		aaaa, _ := dns.NewRR("v6.example.com. 600 IN AAAA 2001:db8::1")
+		envelopes := make(chan *dns.Envelope, 2)
+		envelopes <- &dns.Envelope{RR: []dns.RR{soa, a, cname}}
+		envelopes <- &dns.Envelope{RR: []dns.RR{aaaa, soa}}
+		close(envelopes)
+		if err := new(dns.Transfer).Out(w, r, envelopes); err != nil {
+			t.Errorf("AXFR发送失败: %v", err)
+		}
+	})
+
+	started := make(chan struct{})
+	server := &dns.Server{
+		Listener:          listener,
+		Handler:           handler,
+		TsigSecret:        map[string]string{testTsigName: testTsigSecret},
+		NotifyStartedFunc: func() { close(started) },
+	}
+	go server.ActivateAndServe()
+	<-started
+	t.Cleanup(func() { server.Shutdown() })
+	return listener.Addr().String()
+}
+
+/**
+* 通过AXFR拉取zone，默认使用hmac-sha256签名，结尾重复的SOA记录去掉
+ * @param t
+*/
+func TestBindProviderAXFR(t *testing.T) {
+	address := startAXFRServer(t)
+	provider, err := NewBindProvider(ProviderConfig{Name: "bind", Type: "bind", Options: map[string]interface{}{
+		"axfr_server": address,
+		"axfr_zones":  []string{"Example.com."},
+		"tsig_name":   "axfr-key",
+		"tsig_secret": testTsigSecret,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if algo := provider.(*BindProvider).tsigAlgo; algo != dns.HmacSHA256 {
+		t.Fatalf("默认TSIG算法 = %s, want %s", algo, dns.HmacSHA256)
+	}
+
+	domains, err := provider.DescribeDomains()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(domains, []string{"example.com"}) {
+		t.Fatalf("DescribeDomains = %v, want [example.com]", domains)
+	}
+
+	records, err := provider.DescribeDomainRecords("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, record := range records {
+		got = append(got, record.Type+" "+record.Host()+" "+record.Value)
+	}
+	want := []string{
+		"SOA example.com ns1.example.com. admin.example.com. 1 3600 600 86400 600",
+		"A example.com 192.0.2.1",
+		"CNAME www.example.com example.com",
+		"AAAA v6.example.com 2001:db8::1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("DescribeDomainRecords = %q, want %q", got, want)
+	}
+}
+
+/**
+* TSIG密钥错误、没有签名或服务端拒绝传送时返回错误，不能当作空zone
+ * @param t
+*/
+func TestBindProviderAXFRFailure(t *testing.T) {
+	address := startAXFRServer(t)
+	tests := []struct {
+		name    string
+		zone    string
+		options map[string]interface{}
+		want    string
+	}{
+		{"wrong secret", "example.com", map[string]interface{}{"tsig_name": "axfr-key", "tsig_secret": "d3Jvbmctc2VjcmV0"}, ""},
+		{"no tsig", "example.com", map[string]interface{}{}, "bad xfr rcode"},
+		{"refused", "refused.example.com", map[string]interface{}{"tsig_name": "axfr-key", "tsig_secret": testTsigSecret}, "bad xfr rcode"},
+	}
+	for _, test := range tests {
+		options := map[string]interface{}{"axfr_server": address, "axfr_zones": []string{test.zone}}
+		for key, value := range test.options {
+			options[key] = value
+		}
+		provider, err := NewBindProvider(ProviderConfig{Name: "bind", Type: "bind", Options: options})
+		if err != nil {
+			t.Fatal(err)
+		}
+		provider.(*BindProvider).timeout = 5 * time.Second
+		if _, err := provider.DescribeDomains(); err != nil {
+			t.Fatal(err)
+		}
+
+		records, err := provider.DescribeDomainRecords(test.zone)
+		if err == nil {
+			t.Errorf("%s: DescribeDomainRecords = %+v, want error", test.name, records)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: err = %v, want %s", test.name, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Cloudflare v4 API默认地址，测试时可以通过api_url指向本地模拟服务
+const cloudflareDefaultAPI = "https://api.cloudflare.com/client/v4"
+
+// 定义Cloudflare DNS服务商
+type CloudflareProvider struct {
+	name     string
+	apiURL   string
+	apiToken string
+	perPage  int
+	client   *http.Client
+	// 域名与zone id的对应关系，每次DescribeDomains时重新生成
+	zoneIDs map[string]string
+}
+
+// 定义Cloudflare接口的通用返回结构体
+type cloudflareResponse struct {
+	Success bool `json:"success"`
+	Errors  []struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+	Result     json.RawMessage `json:"result"`
+	ResultInfo struct {
+		Page       int `json:"page"`
+		PerPage    int `json:"per_page"`
+		TotalPages int `json:"total_pages"`
+		Count      int `json:"count"`
+		TotalCount int `json:"total_count"`
+	} `json:"result_info"`
+}
+
+// 定义Cloudflare zone结构体
+type cloudflareZone struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// 定义Cloudflare解析记录结构体
+type cloudflareRecord struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	Comment string `json:"comment"`
+}
+
+/**
+* 根据配置创建Cloudflare DNS服务商
+ * @param conf
+ * @return DNSProvider
+ * @return error
+*/
+func NewCloudflareProvider(conf ProviderConfig) (provider DNSProvider, _err error) {
+	apiToken := conf.GetString("api_token")
+	if apiToken == "" {
+		return nil, fmt.Errorf("%s缺少api_token配置", conf.Name)
+	}
+
+	apiURL := conf.GetString("api_url")
+	if apiURL == "" {
+		apiURL = cloudflareDefaultAPI
+	}
+
+	return &CloudflareProvider{
+		name:     conf.Name,
+		apiURL:   strings.TrimSuffix(apiURL, "/"),
+		apiToken: apiToken,
+		perPage:  50,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		zoneIDs:  make(map[string]string),
+	}, nil
+}
+
+/**
+* 服务商名称
+ * @return string
+*/
+func (p *CloudflareProvider) Name() string {
+	return p.name
+}
+
+/**
+* 调用Cloudflare GET接口
+ * @param path
+ * @param query
+ * @return *cloudflareResponse
+ * @return error
+*/
+func (p *CloudflareProvider) get(path string, query url.Values) (response *cloudflareResponse, _err error) {
+	req, _err := http.NewRequest("GET", p.apiURL+path+"?"+query.Encode(), nil)
+	if _err != nil {
+		return nil, _err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, _err := p.client.Do(req)
+	if _err != nil {
+		return nil, _err
+	}
+	defer resp.Body.Close()
+
+	body, _err := ioutil.ReadAll(resp.Body)
+	if _err != nil {
+		return nil, _err
+	}
+
+	response = &cloudflareResponse{}
+	if _err = json.Unmarshal(body, response); _err != nil {
+		return nil, fmt.Errorf("解析Cloudflare响应异常(状态码%d): %v", resp.StatusCode, _err)
+	}
+	if !response.Success {
+		var messages []string
+		for _, e := range response.Errors {
+			messages = append(messages, fmt.Sprintf("%d %s", e.Code, e.Message))
+		}
+		return nil, fmt.Errorf("调用Cloudflare接口返回错误(状态码%d): %s", resp.StatusCode, strings.Join(messages, "; "))
+	}
+	return response, nil
+}
+
+/**
+* 查询Cloudflare域名(zone)列表
+ * @return []string
+ * @return error
+*/
+func (p *CloudflareProvider) DescribeDomains() (domains []string, _err error) {
+	// 常驻进程中会重复调用，每次重新收集zone，全部查询成功后再替换上一次的结果，上游删除的zone不再保留
+	zoneIDs := make(map[string]string)
+	// 定义初始页码
+	page := 1
+
+	for {
+		query := url.Values{}
+		query.Set("page", fmt.Sprint(page))
+		query.Set("per_page", fmt.Sprint(p.perPage))
+
+		response, _err := p.get("/zones", query)
+		if _err != nil {
+			return nil, _err
+		}
+
+		var zones []cloudflareZone
+		if _err = json.Unmarshal(response.Result, &zones); _err != nil {
+			return nil, _err
+		}
+		for _, zone := range zones {
+			zoneIDs[zone.Name] = zone.ID
+			domains = append(domains, zone.Name)
+		}
+
+		// 最后一页或者没有数据时结束
+		if len(zones) == 0 || page >= response.ResultInfo.TotalPages {
+			p.zoneIDs = zoneIDs
+			return domains, nil
+		}
+		// 更新页码以获取下一页
+		page++
+	}
+}
+
+/**
+* 查询Cloudflare域名解析记录
+ * @param domainName
+ * @return []DomainRecord
+ * @return error
+*/
+func (p *CloudflareProvider) DescribeDomainRecords(domainName string) (records []DomainRecord, _err error) {
+	zoneID, ok := p.zoneIDs[domainName]
+	if !ok {
+		return nil, fmt.Errorf("未找到域名%s对应的Cloudflare zone", domainName)
+	}
+
+	// 定义初始页码
+	page := 1
+
+	for {
+		query := url.Values{}
+		query.Set("page", fmt.Sprint(page))
+		query.Set("per_page", fmt.Sprint(p.perPage))
+
+		response, _err := p.get("/zones/"+zoneID+"/dns_records", query)
+		if _err != nil {
+			return nil, _err
+		}
+
+		var items []cloudflareRecord
+		if _err = json.Unmarshal(response.Result, &items); _err != nil {
+			return nil, _err
+		}
+		for _, item := range items {
+			records = append(records, DomainRecord{
+				Provider: p.name,
+				Zone:     domainName,
+				RR:       relativeName(item.Name, domainName),
+				Type:     item.Type,
+				Value:    item.Content,
+				// Cloudflare没有暂停解析的概念，返回的记录都视为启用
+				Status: "ENABLE",
+				Remark: item.Comment,
+			})
+		}
+
+		// 最后一页或者没有数据时结束
+		if len(items) == 0 || page >= response.ResultInfo.TotalPages {
+			return records, nil
+		}
+		// 更新页码以获取下一页
+		page++
+	}
+}
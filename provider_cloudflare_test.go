@@ -0,0 +1,159 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+/**
+* 模拟Cloudflare接口，items按page/per_page分页返回，result_info.total_pages为总页数
+ * @param t
+ * @param items
+ * @param perPage
+ * @param page
+ * @return []byte
+*/
+func cloudflarePage(t *testing.T, items []interface{}, perPage int, page int) []byte {
+	start := (page - 1) * perPage
+	end := start + perPage
+	if start > len(items) {
+		start = len(items)
+	}
+	if end > len(items) {
+		end = len(items)
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"success": true,
+		"result":  items[start:end],
+		"result_info": map[string]int{
+			"page":        page,
+			"per_page":    perPage,
+			"total_pages": (len(items) + perPage - 1) / perPage,
+			"total_count": len(items),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+/**
+* 通过api_url指向本地模拟服务，检查分页和记录转换
+ * @param t
+*/
+func TestCloudflareProviderPagination(t *testing.T) {
+	zones := []interface{}{
+		cloudflareZone{ID: "z1", Name: "example.com"},
+		cloudflareZone{ID: "z2", Name: "example.net"},
+		cloudflareZone{ID: "z3", Name: "example.org"},
+	}
+	records := []interface{}{
+		cloudflareRecord{ID: "r1", Type: "A", Name: "example.com", Content: "192.0.2.1"},
+		cloudflareRecord{ID: "r2", Type: "CNAME", Name: "www.example.com", Content: "example.com", Comment: "官网"},
+		cloudflareRecord{ID: "r3", Type: "AAAA", Name: "v6.example.com", Content: "2001:db8::1"},
+	}
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"success":false,"errors":[{"code":9109,"message":"Invalid access token"}]}`))
+			return
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		switch r.URL.Path {
+		case "/client/v4/zones":
+			w.Write(cloudflarePage(t, zones, perPage, page))
+		case "/client/v4/zones/z1/dns_records":
+			w.Write(cloudflarePage(t, records, perPage, page))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"success":false,"errors":[{"code":7003,"message":"Could not route"}]}`))
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewCloudflareProvider(ProviderConfig{Name: "cloudflare", Type: "cloudflare", Options: map[string]interface{}{
+		"api_token": "test-token",
+		"api_url":   server.URL + "/client/v4/",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 每页2条，3个zone需要请求2页
+	provider.(*CloudflareProvider).perPage = 2
+
+	domains, err := provider.DescribeDomains()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"example.com", "example.net", "example.org"}; !reflect.DeepEqual(domains, want) {
+		t.Fatalf("DescribeDomains = %v, want %v", domains, want)
+	}
+	if requests != 2 {
+		t.Fatalf("DescribeDomains请求了%d次, want 2", requests)
+	}
+
+	got, err := provider.DescribeDomainRecords("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DomainRecord{
+		{Provider: "cloudflare", Zone: "example.com", RR: "@", Type: "A", Value: "192.0.2.1", Status: "ENABLE"},
+		{Provider: "cloudflare", Zone: "example.com", RR: "www", Type: "CNAME", Value: "example.com", Status: "ENABLE", Remark: "官网"},
+		{Provider: "cloudflare", Zone: "example.com", RR: "v6", Type: "AAAA", Value: "2001:db8::1", Status: "ENABLE"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("DescribeDomainRecords = %+v, want %+v", got, want)
+	}
+
+	// 没有在域名列表中出现的zone直接报错
+	if _, err = provider.DescribeDomainRecords("unknown.com"); err == nil {
+		t.Fatal("DescribeDomainRecords(unknown.com)应该返回错误")
+	}
+
+	// daemon模式下每次同步都会重新调用，上游删除的zone不再保留
+	zones = zones[:1]
+	if domains, err = provider.DescribeDomains(); err != nil || !reflect.DeepEqual(domains, []string{"example.com"}) {
+		t.Fatalf("第二次DescribeDomains = %v, %v", domains, err)
+	}
+	if _, err = provider.DescribeDomainRecords("example.net"); err == nil {
+		t.Fatal("已删除的zone example.net应该返回错误")
+	}
+}
+
+/**
+* 接口返回success=false时带上错误码和错误信息
+ * @param t
+*/
+func TestCloudflareProviderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"success":false,"errors":[{"code":9109,"message":"Invalid access token"}]}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewCloudflareProvider(ProviderConfig{Name: "cloudflare", Type: "cloudflare", Options: map[string]interface{}{
+		"api_token": "bad-token",
+		"api_url":   server.URL,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = provider.DescribeDomains(); err == nil {
+		t.Fatal("DescribeDomains应该返回错误")
+	} else if want := "调用Cloudflare接口返回错误(状态码403): 9109 Invalid access token"; err.Error() != want {
+		t.Fatalf("DescribeDomains错误 = %q, want %q", err.Error(), want)
+	}
+}
@@ -0,0 +1,344 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 华为云APIG签名算法名称和时间格式
+const (
+	huaweiSignAlgorithm  = "SDK-HMAC-SHA256"
+	huaweiSignDateFormat = "20060102T150405Z"
+)
+
+// 定义华为云DNS服务商
+type HuaweiProvider struct {
+	name      string
+	endpoint  string
+	accessKey string
+	secretKey string
+	projectID string
+	limit     int
+	client    *http.Client
+	// 域名与zone id的对应关系，每次DescribeDomains时重新生成
+	zoneIDs map[string]string
+}
+
+// 定义华为云公网域名列表返回结构体
+type huaweiZonesResponse struct {
+	Zones []struct {
+		ID     string `json:"id"`
+		Name   string `json:"name"`
+		Status string `json:"status"`
+	} `json:"zones"`
+	Metadata struct {
+		TotalCount int `json:"total_count"`
+	} `json:"metadata"`
+}
+
+// 定义华为云记录集列表返回结构体
+type huaweiRecordsetsResponse struct {
+	Recordsets []struct {
+		ID          string   `json:"id"`
+		Name        string   `json:"name"`
+		Type        string   `json:"type"`
+		Status      string   `json:"status"`
+		Description string   `json:"description"`
+		Records     []string `json:"records"`
+	} `json:"recordsets"`
+	Metadata struct {
+		TotalCount int `json:"total_count"`
+	} `json:"metadata"`
+}
+
+// 定义华为云接口错误返回结构体
+type huaweiErrorResponse struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"error_code"`
+	ErrorMsg  string `json:"error_msg"`
+}
+
+/**
+* 根据配置创建华为云DNS服务商
+ * @param conf
+ * @return DNSProvider
+ * @return error
+*/
+func NewHuaweiProvider(conf ProviderConfig) (provider DNSProvider, _err error) {
+	// 华为云密钥
+	accessKey := conf.GetString("huawei_key")
+	secretKey := conf.GetString("huawei_secret")
+	if accessKey == "" || secretKey == "" {
+		return nil, fmt.Errorf("%s缺少huawei_key或huawei_secret配置", conf.Name)
+	}
+
+	// 没有配置endpoint时根据region拼接，region也没有配置时使用全局终端节点
+	endpoint := conf.GetString("endpoint")
+	if endpoint == "" {
+		if region := conf.GetString("region"); region != "" {
+			endpoint = "https://dns." + region + ".myhuaweicloud.com"
+		} else {
+			endpoint = "https://dns.myhuaweicloud.com"
+		}
+	}
+
+	return &HuaweiProvider{
+		name:      conf.Name,
+		endpoint:  strings.TrimSuffix(endpoint, "/"),
+		accessKey: accessKey,
+		secretKey: secretKey,
+		projectID: conf.GetString("project_id"),
+		limit:     500,
+		client:    &http.Client{Timeout: 30 * time.Second},
+		zoneIDs:   make(map[string]string),
+	}, nil
+}
+
+/**
+* 服务商名称
+ * @return string
+*/
+func (p *HuaweiProvider) Name() string {
+	return p.name
+}
+
+/**
+* 调用华为云GET接口，请求使用AK/SK签名
+ * @param path
+ * @param query
+ * @param result
+ * @return error
+*/
+func (p *HuaweiProvider) get(path string, query url.Values, result interface{}) (_err error) {
+	req, _err := http.NewRequest("GET", p.endpoint+path+"?"+query.Encode(), nil)
+	if _err != nil {
+		return _err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.projectID != "" {
+		req.Header.Set("X-Project-Id", p.projectID)
+	}
+	huaweiSignRequest(req, nil, p.accessKey, p.secretKey, time.Now())
+
+	resp, _err := p.client.Do(req)
+	if _err != nil {
+		return _err
+	}
+	defer resp.Body.Close()
+
+	body, _err := ioutil.ReadAll(resp.Body)
+	if _err != nil {
+		return _err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		errResp := huaweiErrorResponse{}
+		_ = json.Unmarshal(body, &errResp)
+		if errResp.ErrorCode != "" {
+			return fmt.Errorf("调用华为云接口返回错误(状态码%d): %s %s", resp.StatusCode, errResp.ErrorCode, errResp.ErrorMsg)
+		}
+		return fmt.Errorf("调用华为云接口返回错误(状态码%d): %s %s", resp.StatusCode, errResp.Code, errResp.Message)
+	}
+	return json.Unmarshal(body, result)
+}
+
+/**
+* 查询华为云公网域名列表
+ * @return []string
+ * @return error
+*/
+func (p *HuaweiProvider) DescribeDomains() (domains []string, _err error) {
+	// 常驻进程中会重复调用，每次重新收集zone，全部查询成功后再替换上一次的结果，上游删除的zone不再保留
+	zoneIDs := make(map[string]string)
+	offset := 0
+
+	for {
+		query := url.Values{}
+		query.Set("type", "public")
+		query.Set("limit", fmt.Sprint(p.limit))
+		query.Set("offset", fmt.Sprint(offset))
+
+		response := huaweiZonesResponse{}
+		if _err = p.get("/v2/zones", query, &response); _err != nil {
+			return nil, _err
+		}
+
+		for _, zone := range response.Zones {
+			// 域名以.结尾，统一去掉
+			domainName := strings.TrimSuffix(zone.Name, ".")
+			zoneIDs[domainName] = zone.ID
+			domains = append(domains, domainName)
+		}
+
+		// 已经取完或者没有数据时结束
+		offset += len(response.Zones)
+		if len(response.Zones) == 0 || offset >= response.Metadata.TotalCount {
+			p.zoneIDs = zoneIDs
+			return domains, nil
+		}
+	}
+}
+
+/**
+* 查询华为云域名的记录集
+ * @param domainName
+ * @return []DomainRecord
+ * @return error
+*/
+func (p *HuaweiProvider) DescribeDomainRecords(domainName string) (records []DomainRecord, _err error) {
+	zoneID, ok := p.zoneIDs[domainName]
+	if !ok {
+		return nil, fmt.Errorf("未找到域名%s对应的华为云zone", domainName)
+	}
+
+	offset := 0
+
+	for {
+		query := url.Values{}
+		query.Set("limit", fmt.Sprint(p.limit))
+		query.Set("offset", fmt.Sprint(offset))
+
+		response := huaweiRecordsetsResponse{}
+		if _err = p.get("/v2/zones/"+zoneID+"/recordsets", query, &response); _err != nil {
+			return nil, _err
+		}
+
+		for _, recordset := range response.Recordsets {
+			// 华为云记录集状态ACTIVE表示正常，统一转换成ENABLE，其它状态保持原样
+			status := recordset.Status
+			if status == "ACTIVE" {
+				status = "ENABLE"
+			}
+
+			// 一个记录集可以包含多个记录值，每个值都转换成一条解析记录
+			for _, value := range recordset.Records {
+				records = append(records, DomainRecord{
+					Provider: p.name,
+					Zone:     domainName,
+					RR:       relativeName(recordset.Name, domainName),
+					Type:     recordset.Type,
+					Value:    value,
+					Status:   status,
+					Remark:   recordset.Description,
+				})
+			}
+		}
+
+		// 已经取完或者没有数据时结束
+		offset += len(response.Recordsets)
+		if len(response.Recordsets) == 0 || offset >= response.Metadata.TotalCount {
+			return records, nil
+		}
+	}
+}
+
+/**
+* 华为云AK/SK签名(SDK-HMAC-SHA256)，签名结果写入Authorization请求头
+ * @param req
+ * @param body
+ * @param accessKey
+ * @param secretKey
+ * @param now
+*/
+func huaweiSignRequest(req *http.Request, body []byte, accessKey string, secretKey string, now time.Time) {
+	req.Header.Set("X-Sdk-Date", now.UTC().Format(huaweiSignDateFormat))
+	req.Header.Set("Host", req.URL.Host)
+
+	// 参与签名的请求头，key转小写后排序
+	var signedHeaders []string
+	headers := make(map[string]string)
+	for key, values := range req.Header {
+		lowerKey := strings.ToLower(key)
+		// Content-Type不参与签名，与官方SDK保持一致
+		if lowerKey == "content-type" {
+			continue
+		}
+		signedHeaders = append(signedHeaders, lowerKey)
+		headers[lowerKey] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	sort.Strings(signedHeaders)
+
+	var canonicalHeaders strings.Builder
+	for _, key := range signedHeaders {
+		canonicalHeaders.WriteString(key + ":" + headers[key] + "\n")
+	}
+
+	// 规范URI，每段单独编码，并且必须以/结尾
+	segments := strings.Split(req.URL.Path, "/")
+	for i, segment := range segments {
+		segments[i] = huaweiEscape(segment)
+	}
+	canonicalURI := strings.Join(segments, "/")
+	if !strings.HasSuffix(canonicalURI, "/") {
+		canonicalURI += "/"
+	}
+
+	// 规范查询字符串，按key排序
+	query := req.URL.Query()
+	var keys []string
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var queryParts []string
+	for _, key := range keys {
+		values := query[key]
+		sort.Strings(values)
+		for _, value := range values {
+			queryParts = append(queryParts, huaweiEscape(key)+"="+huaweiEscape(value))
+		}
+	}
+
+	bodyHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		strings.Join(queryParts, "&"),
+		canonicalHeaders.String(),
+		strings.Join(signedHeaders, ";"),
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := huaweiSignAlgorithm + "\n" + req.Header.Get("X-Sdk-Date") + "\n" + hex.EncodeToString(requestHash[:])
+
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(stringToSign))
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Access=%s, SignedHeaders=%s, Signature=%s",
+		huaweiSignAlgorithm, accessKey, strings.Join(signedHeaders, ";"), signature))
+}
+
+/**
+* 华为云签名使用的URL编码，只保留RFC 3986中的非保留字符
+ * @param s
+ * @return string
+*/
+func huaweiEscape(s string) string {
+	var builder strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			builder.WriteByte(c)
+		} else {
+			builder.WriteString(fmt.Sprintf("%%%02X", c))
+		}
+	}
+	return builder.String()
+}
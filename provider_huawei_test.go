@@ -0,0 +1,154 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+/**
+* 固定时间的签名向量，期望值按华为云APIG签名算法独立计算
+ * @param t
+*/
+func TestHuaweiSignRequest(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		method  string
+		url     string
+		body    []byte
+		headers map[string]string
+		want    string
+	}{
+		{
+			method: "GET",
+			url:    "https://dns.myhuaweicloud.com/v2/zones?offset=0&name=example.com.&limit=500",
+			want:   "SDK-HMAC-SHA256 Access=AKEXAMPLE, SignedHeaders=host;x-sdk-date, Signature=9f307be6d8f19a6aadb17b7340aed83162005bda3cc8074f0d2c1094e30d06c6",
+		},
+		{
+			// Content-Type不参与签名，其他请求头参与签名
+			method:  "POST",
+			url:     "https://dns.myhuaweicloud.com/v2/zones/ZID/recordsets",
+			body:    []byte(`{"a":1}`),
+			headers: map[string]string{"Content-Type": "application/json", "X-Project-Id": "p1"},
+			want:    "SDK-HMAC-SHA256 Access=AKEXAMPLE, SignedHeaders=host;x-project-id;x-sdk-date, Signature=2f01875bdbf4b9f0300d4e8db679b8520a132d34788fb7e3378d8bc42604998b",
+		},
+	}
+
+	for _, test := range tests {
+		req, err := http.NewRequest(test.method, test.url, bytes.NewReader(test.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for key, value := range test.headers {
+			req.Header.Set(key, value)
+		}
+		huaweiSignRequest(req, test.body, "AKEXAMPLE", "SKEXAMPLE", now)
+
+		if got := req.Header.Get("X-Sdk-Date"); got != "20260102T030405Z" {
+			t.Errorf("%s %s X-Sdk-Date = %s", test.method, test.url, got)
+		}
+		if got := req.Header.Get("Authorization"); got != test.want {
+			t.Errorf("%s %s Authorization = %s, want %s", test.method, test.url, got, test.want)
+		}
+	}
+}
+
+/**
+* 签名使用的URL编码只保留RFC 3986非保留字符
+ * @param t
+*/
+func TestHuaweiEscape(t *testing.T) {
+	if got, want := huaweiEscape("a b/*~-_.中"), "a%20b%2F%2A~-_.%E4%B8%AD"; got != want {
+		t.Fatalf("huaweiEscape = %s, want %s", got, want)
+	}
+}
+
+/**
+* 通过endpoint指向本地模拟服务，检查offset/total_count分页、ACTIVE状态转换、多值记录集展开和错误返回的解析
+ * @param t
+*/
+func TestHuaweiProvider(t *testing.T) {
+	// 第二次查询域名列表时example.net已经被删除
+	deleted := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), huaweiSignAlgorithm+" Access=AKEXAMPLE,") {
+			t.Errorf("请求没有签名: %s", r.Header.Get("Authorization"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		offset := r.URL.Query().Get("offset")
+		switch {
+		case r.URL.Path == "/v2/zones" && deleted:
+			w.Write([]byte(`{"zones":[{"id":"Z1","name":"example.com.","status":"ACTIVE"}],"metadata":{"total_count":1}}`))
+		case r.URL.Path == "/v2/zones" && offset == "0":
+			w.Write([]byte(`{"zones":[{"id":"Z1","name":"example.com.","status":"ACTIVE"}],"metadata":{"total_count":2}}`))
+		case r.URL.Path == "/v2/zones" && offset == "1":
+			w.Write([]byte(`{"zones":[{"id":"Z2","name":"example.net.","status":"ACTIVE"}],"metadata":{"total_count":2}}`))
+		case r.URL.Path == "/v2/zones/Z1/recordsets" && offset == "0":
+			w.Write([]byte(`{"recordsets":[{"id":"R1","name":"www.example.com.","type":"A","status":"ACTIVE","description":"官网","records":["192.0.2.1","192.0.2.2"]}],"metadata":{"total_count":2}}`))
+		case r.URL.Path == "/v2/zones/Z1/recordsets" && offset == "1":
+			w.Write([]byte(`{"recordsets":[{"id":"R2","name":"example.com.","type":"CNAME","status":"DISABLE","records":["lb.example.net."]}],"metadata":{"total_count":2}}`))
+		case r.URL.Path == "/v2/zones/Z2/recordsets":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error_code":"APIGW.0301","error_msg":"Incorrect IAM authentication information"}`))
+		default:
+			t.Errorf("未预期的请求: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"DNS.0101","message":"not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewHuaweiProvider(ProviderConfig{Name: "huawei", Type: "huawei", Options: map[string]interface{}{
+		"huawei_key":    "AKEXAMPLE",
+		"huawei_secret": "secret",
+		"endpoint":      server.URL + "/",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 每页一条，覆盖分页
+	provider.(*HuaweiProvider).limit = 1
+
+	domains, err := provider.DescribeDomains()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"example.com", "example.net"}; !reflect.DeepEqual(domains, want) {
+		t.Fatalf("DescribeDomains = %v, want %v", domains, want)
+	}
+
+	records, err := provider.DescribeDomainRecords("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DomainRecord{
+		{Provider: "huawei", Zone: "example.com", RR: "www", Type: "A", Value: "192.0.2.1", Status: "ENABLE", Remark: "官网"},
+		{Provider: "huawei", Zone: "example.com", RR: "www", Type: "A", Value: "192.0.2.2", Status: "ENABLE", Remark: "官网"},
+		{Provider: "huawei", Zone: "example.com", RR: "@", Type: "CNAME", Value: "lb.example.net.", Status: "DISABLE"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Fatalf("DescribeDomainRecords = %+v, want %+v", records, want)
+	}
+
+	// 错误返回中的error_code和error_msg带到错误信息中
+	if _, err = provider.DescribeDomainRecords("example.net"); err == nil || !strings.Contains(err.Error(), "APIGW.0301") || !strings.Contains(err.Error(), "状态码403") {
+		t.Fatalf("鉴权失败时返回 %v", err)
+	}
+
+	// 上游删除的zone在下一次同步后不再保留
+	deleted = true
+	if domains, err = provider.DescribeDomains(); err != nil || !reflect.DeepEqual(domains, []string{"example.com"}) {
+		t.Fatalf("第二次DescribeDomains = %v, %v", domains, err)
+	}
+	if _, err = provider.DescribeDomainRecords("example.net"); err == nil || !strings.Contains(err.Error(), "未找到域名") {
+		t.Fatalf("已删除的zone返回 %v", err)
+	}
+}
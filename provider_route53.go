@@ -0,0 +1,193 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/route53"
+)
+
+// 定义AWS Route 53 DNS服务商
+type Route53Provider struct {
+	name   string
+	client *route53.Client
+	// 域名与hosted zone id的对应关系，每次DescribeDomains时重新生成
+	zoneIDs map[string]string
+}
+
+/**
+* 初始化AWS Route 53 SDK，默认使用AWS标准凭证链(环境变量、~/.aws配置文件、实例角色等)
+ * @param conf
+ * @return *route53.Client
+ * @return error
+*/
+func Route53Init(conf ProviderConfig) (r53Client *route53.Client, _err error) {
+	var opts []func(*awsconfig.LoadOptions) error
+
+	// Route 53是全局服务，没有配置region时使用us-east-1
+	region := conf.GetString("region")
+	if region == "" {
+		region = "us-east-1"
+	}
+	opts = append(opts, awsconfig.WithRegion(region))
+
+	// 指定~/.aws/credentials中的profile
+	if profileName := conf.GetString("profile"); profileName != "" {
+		opts = append(opts, awsconfig.WithSharedConfigProfile(profileName))
+	}
+
+	// 配置了access_key时优先使用静态密钥
+	if accessKey := conf.GetString("access_key"); accessKey != "" {
+		opts = append(opts, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(accessKey, conf.GetString("secret_key"), conf.GetString("session_token"))))
+	}
+
+	cfg, _err := awsconfig.LoadDefaultConfig(context.TODO(), opts...)
+	if _err != nil {
+		return nil, _err
+	}
+
+	// endpoint用于指向本地模拟服务
+	endpoint := conf.GetString("endpoint")
+	client := route53.NewFromConfig(cfg, func(o *route53.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	})
+	return client, nil
+}
+
+/**
+* 根据配置创建AWS Route 53 DNS服务商
+ * @param conf
+ * @return DNSProvider
+ * @return error
+*/
+func NewRoute53Provider(conf ProviderConfig) (provider DNSProvider, _err error) {
+	client, _err := Route53Init(conf)
+	if _err != nil {
+		return nil, _err
+	}
+	return &Route53Provider{name: conf.Name, client: client, zoneIDs: make(map[string]string)}, nil
+}
+
+/**
+* 服务商名称
+ * @return string
+*/
+func (p *Route53Provider) Name() string {
+	return p.name
+}
+
+/**
+* 查询Route 53托管区域(hosted zone)列表
+ * @return []string
+ * @return error
+*/
+func (p *Route53Provider) DescribeDomains() (domains []string, _err error) {
+	// 常驻进程中会重复调用，每次重新收集托管区域，全部查询成功后再替换上一次的结果
+	zoneIDs := make(map[string]string)
+	paginator := route53.NewListHostedZonesPaginator(p.client, &route53.ListHostedZonesInput{})
+
+	for paginator.HasMorePages() {
+		page, _err := paginator.NextPage(context.TODO())
+		if _err != nil {
+			return nil, _err
+		}
+
+		for _, zone := range page.HostedZones {
+			// 托管区域名称以.结尾，统一去掉
+			domainName := strings.TrimSuffix(aws.ToString(zone.Name), ".")
+			if _, ok := zoneIDs[domainName]; ok {
+				errlogger.Printf("Route 53存在同名托管区域%s，忽略%s", domainName, aws.ToString(zone.Id))
+				continue
+			}
+			zoneIDs[domainName] = aws.ToString(zone.Id)
+			domains = append(domains, domainName)
+		}
+	}
+	p.zoneIDs = zoneIDs
+	return domains, nil
+}
+
+/**
+* 查询Route 53托管区域的解析记录，别名记录(指向ELB/CloudFront等)的记录值为别名目标
+ * @param domainName
+ * @return []DomainRecord
+ * @return error
+*/
+func (p *Route53Provider) DescribeDomainRecords(domainName string) (records []DomainRecord, _err error) {
+	zoneID, ok := p.zoneIDs[domainName]
+	if !ok {
+		return nil, fmt.Errorf("未找到域名%s对应的Route 53托管区域", domainName)
+	}
+
+	paginator := route53.NewListResourceRecordSetsPaginator(p.client, &route53.ListResourceRecordSetsInput{
+		HostedZoneId: aws.String(zoneID),
+	})
+
+	for paginator.HasMorePages() {
+		page, _err := paginator.NextPage(context.TODO())
+		if _err != nil {
+			return nil, _err
+		}
+
+		for _, recordSet := range page.ResourceRecordSets {
+			record := DomainRecord{
+				Provider: p.name,
+				Zone:     domainName,
+				RR:       relativeName(unescapeRoute53Name(aws.ToString(recordSet.Name)), domainName),
+				Type:     string(recordSet.Type),
+				// Route 53没有暂停解析的概念，返回的记录都视为启用
+				Status: "ENABLE",
+			}
+
+			// 别名记录没有ResourceRecords，记录值取别名目标
+			if recordSet.AliasTarget != nil {
+				record.Value = strings.TrimSuffix(aws.ToString(recordSet.AliasTarget.DNSName), ".")
+				record.Alias = true
+				records = append(records, record)
+				continue
+			}
+
+			for _, resourceRecord := range recordSet.ResourceRecords {
+				record.Value = aws.ToString(resourceRecord.Value)
+				records = append(records, record)
+			}
+		}
+	}
+	return records, nil
+}
+
+/**
+* Route 53返回的记录名会把*等特殊字符转义成\052这种八进制形式，这里还原回来
+ * @param name
+ * @return string
+*/
+func unescapeRoute53Name(name string) string {
+	if !strings.Contains(name, `\`) {
+		return name
+	}
+
+	var builder strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] == '\\' && i+3 < len(name) {
+			var c int
+			if _, err := fmt.Sscanf(name[i+1:i+4], "%03o", &c); err == nil {
+				builder.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		builder.WriteByte(name[i])
+	}
+	return builder.String()
+}
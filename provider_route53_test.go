@@ -0,0 +1,146 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+const route53ZonesPage1 = `<?xml version="1.0" encoding="UTF-8"?>
+<ListHostedZonesResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/">
+  <HostedZones>
+    <HostedZone><Id>/hostedzone/Z1</Id><Name>example.com.</Name><CallerReference>1</CallerReference></HostedZone>
+  </HostedZones>
+  <IsTruncated>true</IsTruncated>
+  <NextMarker>Z2</NextMarker>
+  <MaxItems>1</MaxItems>
+</ListHostedZonesResponse>`
+
+const route53ZonesPage2 = `<?xml version="1.0" encoding="UTF-8"?>
+<ListHostedZonesResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/">
+  <HostedZones>
+    <HostedZone><Id>/hostedzone/Z2</Id><Name>example.net.</Name><CallerReference>2</CallerReference></HostedZone>
+  </HostedZones>
+  <IsTruncated>false</IsTruncated>
+  <Marker>Z2</Marker>
+  <MaxItems>1</MaxItems>
+</ListHostedZonesResponse>`
+
+const route53RecordSets = `<?xml version="1.0" encoding="UTF-8"?>
+<ListResourceRecordSetsResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/">
+  <ResourceRecordSets>
+    <ResourceRecordSet>
+      <Name>example.com.</Name><Type>A</Type><TTL>300</TTL>
+      <ResourceRecords>
+        <ResourceRecord><Value>192.0.2.1</Value></ResourceRecord>
+        <ResourceRecord><Value>192.0.2.2</Value></ResourceRecord>
+      </ResourceRecords>
+    </ResourceRecordSet>
+    <ResourceRecordSet>
+      <Name>\052.example.com.</Name><Type>CNAME</Type><TTL>300</TTL>
+      <ResourceRecords><ResourceRecord><Value>example.com</Value></ResourceRecord></ResourceRecords>
+    </ResourceRecordSet>
+    <ResourceRecordSet>
+      <Name>www.example.com.</Name><Type>A</Type>
+      <AliasTarget>
+        <HostedZoneId>Z35SXDOTRQ7X7K</HostedZoneId>
+        <DNSName>dualstack.web-123.us-east-1.elb.amazonaws.com.</DNSName>
+        <EvaluateTargetHealth>false</EvaluateTargetHealth>
+      </AliasTarget>
+    </ResourceRecordSet>
+  </ResourceRecordSets>
+  <IsTruncated>false</IsTruncated>
+  <MaxItems>300</MaxItems>
+</ListResourceRecordSetsResponse>`
+
+/**
+* 通过endpoint(BaseEndpoint)指向本地模拟服务，检查托管区域分页、别名记录和转义记录名的转换
+ * @param t
+*/
+func TestRoute53Provider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml")
+		switch {
+		case r.URL.Path == "/2013-04-01/hostedzone" && r.URL.Query().Get("marker") == "":
+			w.Write([]byte(route53ZonesPage1))
+		case r.URL.Path == "/2013-04-01/hostedzone" && r.URL.Query().Get("marker") == "Z2":
+			w.Write([]byte(route53ZonesPage2))
+		case r.URL.Path == "/2013-04-01/hostedzone/Z1/rrset":
+			w.Write([]byte(route53RecordSets))
+		default:
+			t.Errorf("未预期的请求: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewRoute53Provider(ProviderConfig{Name: "route53", Type: "route53", Options: map[string]interface{}{
+		"access_key": "AKIDEXAMPLE",
+		"secret_key": "secret",
+		"endpoint":   server.URL,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	domains, err := provider.DescribeDomains()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"example.com", "example.net"}; !reflect.DeepEqual(domains, want) {
+		t.Fatalf("DescribeDomains = %v, want %v", domains, want)
+	}
+
+	// daemon模式下每次同步都会重新调用，第二次仍应返回全部托管区域
+	domains, err = provider.DescribeDomains()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"example.com", "example.net"}; !reflect.DeepEqual(domains, want) {
+		t.Fatalf("second DescribeDomains = %v, want %v", domains, want)
+	}
+
+	records, err := provider.DescribeDomainRecords("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DomainRecord{
+		{Provider: "route53", Zone: "example.com", RR: "@", Type: "A", Value: "192.0.2.1", Status: "ENABLE"},
+		{Provider: "route53", Zone: "example.com", RR: "@", Type: "A", Value: "192.0.2.2", Status: "ENABLE"},
+		{Provider: "route53", Zone: "example.com", RR: "*", Type: "CNAME", Value: "example.com", Status: "ENABLE"},
+		// 别名记录的值为别名目标，Remark保持为空，避免误匹配按备注配置的归属规则
+		{Provider: "route53", Zone: "example.com", RR: "www", Type: "A", Value: "dualstack.web-123.us-east-1.elb.amazonaws.com", Status: "ENABLE", Alias: true},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Fatalf("DescribeDomainRecords = %+v, want %+v", records, want)
+	}
+}
+
+/**
+* 还原Route 53记录名中的八进制转义
+ * @param t
+*/
+func TestUnescapeRoute53Name(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{`example.com.`, `example.com.`},
+		{`\052.example.com.`, `*.example.com.`},
+		{`a\100b.example.com.`, `a@b.example.com.`},
+		{`\052`, `*`},
+		// 不完整的转义保持原样
+		{`abc\05`, `abc\05`},
+		{`abc\`, `abc\`},
+	}
+	for _, test := range tests {
+		if got := unescapeRoute53Name(test.name); got != test.want {
+			t.Errorf("unescapeRoute53Name(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 静态清单中的记录类型，这类记录是人工维护的，不经过解析记录过滤规则
+const staticRecordType = "STATIC"
+
+// 定义静态清单服务商，从YAML/CSV文件读取没有DNS API权限的HTTPS域名
+type StaticProvider struct {
+	name  string
+	files []string
+	dir   string
+}
+
+// 定义YAML清单中的一项，也可以直接写成"host"或"host:port"字符串
+type staticEntry struct {
+	Host   string            `yaml:"host"`
+	Port   int               `yaml:"port"`
+	Labels map[string]string `yaml:"labels"`
+}
+
+/**
+* 根据配置创建静态清单服务商
+ * @param conf
+ * @return DNSProvider
+ * @return error
+*/
+func NewStaticProvider(conf ProviderConfig) (provider DNSProvider, _err error) {
+	files := conf.GetStringSlice("files")
+	dir := conf.GetString("dir")
+	if len(files) == 0 && dir == "" {
+		return nil, fmt.Errorf("%s没有配置files或dir", conf.Name)
+	}
+	return &StaticProvider{name: conf.Name, files: files, dir: dir}, nil
+}
+
+/**
+* 服务商名称
+ * @return string
+*/
+func (p *StaticProvider) Name() string {
+	return p.name
+}
+
+/**
+* 静态清单没有域名列表的概念，每个清单文件当作一个"域名"返回
+* 常驻进程中每次同步都重新扫描dir，新增的清单文件在下一次同步时生效
+ * @return []string
+ * @return error
+*/
+func (p *StaticProvider) DescribeDomains() (domains []string, _err error) {
+	for _, file := range p.files {
+		if _, _err = os.Stat(file); _err != nil {
+			return nil, _err
+		}
+		domains = append(domains, file)
+	}
+	if p.dir == "" {
+		return domains, nil
+	}
+
+	// dir下的所有yml/yaml/csv文件都当作清单文件
+	entries, _err := ioutil.ReadDir(p.dir)
+	if _err != nil {
+		return nil, _err
+	}
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".yml", ".yaml", ".csv":
+			if !entry.IsDir() {
+				domains = append(domains, filepath.Join(p.dir, entry.Name()))
+			}
+		}
+	}
+	return domains, nil
+}
+
+/**
+* 读取清单文件中的域名
+ * @param file
+ * @return []DomainRecord
+ * @return error
+*/
+func (p *StaticProvider) DescribeDomainRecords(file string) (records []DomainRecord, _err error) {
+	var entries []staticEntry
+	if strings.ToLower(filepath.Ext(file)) == ".csv" {
+		entries, _err = readStaticCSV(file)
+	} else {
+		entries, _err = readStaticYAML(file)
+	}
+	if _err != nil {
+		return nil, fmt.Errorf("读取清单文件%s异常: %v", file, _err)
+	}
+
+	for _, entry := range entries {
+		host := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(entry.Host)), ".")
+		port := entry.Port
+
+		// host中带端口时拆分出来
+		if h, portText, err := net.SplitHostPort(host); err == nil {
+			host = h
+			port, err = strconv.Atoi(portText)
+			if err != nil {
+				return nil, fmt.Errorf("清单文件%s中%s端口格式错误", file, entry.Host)
+			}
+		}
+		if host == "" {
+			continue
+		}
+
+		records = append(records, DomainRecord{
+			Provider: p.name,
+			RR:       host,
+			Type:     staticRecordType,
+			Status:   "ENABLE",
+			Port:     port,
+			Labels:   entry.Labels,
+		})
+	}
+	return records, nil
+}
+
+/**
+* 读取YAML清单，格式为列表，每项可以是字符串或{host, port, labels}
+ * @param file
+ * @return []staticEntry
+ * @return error
+*/
+func readStaticYAML(file string) (entries []staticEntry, _err error) {
+	content, _err := ioutil.ReadFile(file)
+	if _err != nil {
+		return nil, _err
+	}
+
+	var nodes []yaml.Node
+	if _err = yaml.Unmarshal(content, &nodes); _err != nil {
+		return nil, _err
+	}
+
+	for _, node := range nodes {
+		entry := staticEntry{}
+		if node.Kind == yaml.ScalarNode {
+			entry.Host = node.Value
+		} else if _err = node.Decode(&entry); _err != nil {
+			return nil, _err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+/**
+* 读取CSV清单，列依次为host,port,labels，labels格式为k1=v1;k2=v2，#开头的行和表头会被忽略
+ * @param file
+ * @return []staticEntry
+ * @return error
+*/
+func readStaticCSV(file string) (entries []staticEntry, _err error) {
+	f, _err := os.Open(file)
+	if _err != nil {
+		return nil, _err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	for {
+		row, _err := reader.Read()
+		if _err == io.EOF {
+			return entries, nil
+		}
+		if _err != nil {
+			return nil, _err
+		}
+		if len(row) == 0 || strings.EqualFold(row[0], "host") {
+			continue
+		}
+
+		entry := staticEntry{Host: row[0]}
+		if len(row) > 1 && strings.TrimSpace(row[1]) != "" {
+			entry.Port, _err = strconv.Atoi(strings.TrimSpace(row[1]))
+			if _err != nil {
+				return nil, fmt.Errorf("%s端口格式错误: %v", row[0], _err)
+			}
+		}
+		if len(row) > 2 {
+			entry.Labels = parseLabels(row[2])
+		}
+		entries = append(entries, entry)
+	}
+}
+
+/**
+* 解析k1=v1;k2=v2格式的标签
+ * @param text
+ * @return map[string]string
+*/
+func parseLabels(text string) map[string]string {
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(text, ";") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			continue
+		}
+		labels[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return labels
+}
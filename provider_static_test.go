@@ -0,0 +1,163 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+const testStaticYAML = `- WWW.Example.com.
+- legacy.example.com:8443
+- host: intranet.example.com
+  port: 9443
+  labels:
+    team: web
+    env: prod
+`
+
+const testStaticCSV = `host,port,labels
+# 人工维护的内部系统
+oa.example.com,,team=it;env=prod
+vpn.example.com,10443,
+`
+
+/**
+* 读取YAML和CSV清单，域名统一小写并去掉末尾的点，host中带端口时拆分出来
+ * @param t
+*/
+func TestStaticProviderDescribeDomainRecords(t *testing.T) {
+	dir := t.TempDir()
+	yamlFile := filepath.Join(dir, "hosts.yml")
+	csvFile := filepath.Join(dir, "hosts.csv")
+	if err := ioutil.WriteFile(yamlFile, []byte(testStaticYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(csvFile, []byte(testStaticCSV), 0644); err != nil {
+		t.Fatal(err)
+	}
+	provider, err := NewStaticProvider(ProviderConfig{Name: "static", Type: "static", Options: map[string]interface{}{"files": []string{yamlFile, csvFile}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string][]DomainRecord{
+		yamlFile: {
+			{Provider: "static", RR: "www.example.com", Type: staticRecordType, Status: "ENABLE"},
+			{Provider: "static", RR: "legacy.example.com", Type: staticRecordType, Status: "ENABLE", Port: 8443},
+			{Provider: "static", RR: "intranet.example.com", Type: staticRecordType, Status: "ENABLE", Port: 9443, Labels: map[string]string{"team": "web", "env": "prod"}},
+		},
+		csvFile: {
+			{Provider: "static", RR: "oa.example.com", Type: staticRecordType, Status: "ENABLE", Labels: map[string]string{"team": "it", "env": "prod"}},
+			{Provider: "static", RR: "vpn.example.com", Type: staticRecordType, Status: "ENABLE", Port: 10443, Labels: map[string]string{}},
+		},
+	}
+	for file, want := range tests {
+		records, err := provider.DescribeDomainRecords(file)
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		if !reflect.DeepEqual(records, want) {
+			t.Errorf("%s:\n got %+v\nwant %+v", file, records, want)
+		}
+	}
+
+	// 端口格式错误时返回错误
+	badFile := filepath.Join(dir, "bad.csv")
+	if err := ioutil.WriteFile(badFile, []byte("www.example.com,https\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.DescribeDomainRecords(badFile); err == nil {
+		t.Fatal("端口格式错误时没有返回错误")
+	}
+}
+
+/**
+* dir在每次DescribeDomains时重新扫描，启动后新增的清单文件在下一次同步时生效，其它扩展名的文件忽略
+ * @param t
+*/
+func TestStaticProviderDirRescan(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "a.yml"), []byte(testStaticYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+	provider, err := NewStaticProvider(ProviderConfig{Name: "static", Type: "static", Options: map[string]interface{}{"dir": dir}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	domains, err := provider.DescribeDomains()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{filepath.Join(dir, "a.yml")}; !reflect.DeepEqual(domains, want) {
+		t.Fatalf("DescribeDomains = %v, want %v", domains, want)
+	}
+
+	for _, name := range []string{"b.csv", "README.md"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(testStaticCSV), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	domains, err = provider.DescribeDomains()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{filepath.Join(dir, "a.yml"), filepath.Join(dir, "b.csv")}; !reflect.DeepEqual(domains, want) {
+		t.Fatalf("新增清单文件后DescribeDomains = %v, want %v", domains, want)
+	}
+}
+
+/**
+* 静态清单与云服务商的记录对应同一个目标时只保留一条，先加入的云服务商记录优先，静态清单只补充缺少的标签
+ * @param t
+*/
+func TestStaticProviderMergeWithCloudRecords(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	file := filepath.Join(t.TempDir(), "hosts.yml")
+	content := "- host: www.example.com\n  labels:\n    team: web\n- www.example.com:8443\n- test.example.com\n"
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	static, err := NewStaticProvider(ProviderConfig{Name: "static", Type: "static", Options: map[string]interface{}{"files": []string{file}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloud := &fakeDNSProvider{name: "aliyun", records: map[string][]DomainRecord{
+		"example.com": {
+			{Provider: "aliyun", Zone: "example.com", RR: "www", Type: "A", Value: "192.0.2.1", Status: "ENABLE"},
+			{Provider: "aliyun", Zone: "example.com", RR: "www", Type: "A", Value: "192.0.2.2", Status: "ENABLE"},
+		},
+	}}
+	providers := []DNSProvider{cloud, static}
+	if err := DescribeDomains(providers); err != nil {
+		t.Fatal(err)
+	}
+	filter, err := LoadRecordFilter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	inventory := newDomainInventory()
+	for _, provider := range providers {
+		if err := describeProviderRecords(provider, filter, inventory); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 静态清单不经过过滤规则，test.example.com也要检查
+	if got, want := inventory.targets.String(), "www.example.com\nwww.example.com:8443\ntest.example.com\n"; got != want {
+		t.Fatalf("域名清单 = %q, want %q", got, want)
+	}
+	www := inventory.records[inventory.index["www.example.com"]]
+	if www.Provider != "aliyun" || !reflect.DeepEqual(www.IPs, []string{"192.0.2.1", "192.0.2.2"}) || www.Labels["team"] != "web" {
+		t.Fatalf("合并后的记录 = %+v", www)
+	}
+}
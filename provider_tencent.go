@@ -0,0 +1,136 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
+	dnspod "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/dnspod/v20210323"
+)
+
+// 域名下没有解析记录时DescribeRecordList返回的错误码
+const tencentNoDataOfRecord = "ResourceNotFound.NoDataOfRecord"
+
+// 定义腾讯云DNS服务商
+type TencentProvider struct {
+	name   string
+	client *dnspod.Client
+}
+
+/**
+* 初始化腾讯云SDK
+ * @param secretId
+ * @param secretKey
+ * @return *dnspod.Client
+ * @return error
+*/
+func TencentInit(secretId string, secretKey string) (txdnsClient *dnspod.Client, err error) {
+
+	credential := common.NewCredential(
+		secretId,
+		secretKey,
+	)
+
+	// 实例化一个client选项，可选的，没有特殊需求可以跳过
+	cpf := profile.NewClientProfile()
+	cpf.HttpProfile.Endpoint = "dnspod.tencentcloudapi.com"
+
+	// 实例化要请求产品的client对象,clientProfile是可选的
+	txClient, err := dnspod.NewClient(credential, "", cpf)
+	return txClient, err
+}
+
+/**
+* 根据配置创建腾讯云DNS服务商
+ * @param conf
+ * @return DNSProvider
+ * @return error
+*/
+func NewTencentProvider(conf ProviderConfig) (provider DNSProvider, _err error) {
+	// 腾讯云密钥
+	secretId := conf.GetString("tencent_key")
+	secretKey := conf.GetString("tencent_secret")
+
+	client, _err := TencentInit(secretId, secretKey)
+	if _err != nil {
+		return nil, _err
+	}
+	return &TencentProvider{name: conf.Name, client: client}, nil
+}
+
+/**
+* 服务商名称
+ * @return string
+*/
+func (p *TencentProvider) Name() string {
+	return p.name
+}
+
+/**
+* 查询腾讯云域名列表
+ * @return []string
+ * @return error
+*/
+func (p *TencentProvider) DescribeDomains() (domains []string, _err error) {
+
+	// 不用分页，默认显示3000条
+	// 实例化一个请求对象,每个接口都会对应一个request对象
+	request := dnspod.NewDescribeDomainListRequest()
+
+	// 返回的resp是一个DescribeDomainListResponse的实例，与请求对象对应
+	response, _err := p.client.DescribeDomainList(request)
+	if _err != nil {
+		return nil, _err
+	}
+
+	for _, domain := range response.Response.DomainList {
+		domains = append(domains, *domain.Name)
+	}
+	return domains, nil
+}
+
+/**
+* 查询腾讯云域名解析记录
+ * @param domainName
+ * @return []DomainRecord
+ * @return error
+*/
+func (p *TencentProvider) DescribeDomainRecords(domainName string) (records []DomainRecord, _err error) {
+	req := dnspod.NewDescribeRecordListRequest()
+	var offset uint64 = 0
+	// 不用分页，默认显示3000条,最大的域名的所有记录578条
+	var limit uint64 = 3000
+	req.Offset = &offset
+	req.Limit = &limit
+	req.Domain = &domainName
+
+	response, _err := p.client.DescribeRecordList(req)
+	if sdkErr, ok := _err.(*errors.TencentCloudSDKError); ok {
+		// 没有解析记录的域名返回NoDataOfRecord，当作空域名继续同步其他域名
+		if sdkErr.GetCode() == tencentNoDataOfRecord {
+			infologger.Printf("腾讯云域名%s没有解析记录", domainName)
+			return nil, nil
+		}
+		errlogger.Printf("调用腾讯云API返回错误: %v", _err)
+		return nil, _err
+	}
+	if _err != nil {
+		return nil, _err
+	}
+
+	for _, record := range response.Response.RecordList {
+		records = append(records, DomainRecord{
+			Provider: p.name,
+			Zone:     domainName,
+			RR:       stringValue(record.Name),
+			Type:     stringValue(record.Type),
+			Value:    stringValue(record.Value),
+			Status:   stringValue(record.Status),
+			Remark:   stringValue(record.Remark),
+		})
+	}
+	return records, nil
+}
@@ -0,0 +1,76 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
+	dnspod "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/dnspod/v20210323"
+)
+
+/**
+* 创建请求测试服务端的腾讯云DNS服务商，responses的key为请求中的Domain
+ * @param t
+ * @param responses
+ * @return DNSProvider
+*/
+func newTestTencentProvider(t *testing.T, responses map[string]string) DNSProvider {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var request struct {
+			Domain string
+		}
+		json.Unmarshal(body, &request)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(responses[request.Domain]))
+	}))
+	t.Cleanup(server.Close)
+
+	cpf := profile.NewClientProfile()
+	cpf.HttpProfile.Endpoint = strings.TrimPrefix(server.URL, "http://")
+	cpf.HttpProfile.Scheme = "HTTP"
+	client, err := dnspod.NewClient(common.NewCredential("AKIDEXAMPLE", "secret"), "", cpf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &TencentProvider{name: "tencent", client: client}
+}
+
+/**
+* 没有解析记录的域名返回NoDataOfRecord时当作空域名，其他错误照常返回
+ * @param t
+*/
+func TestTencentDescribeDomainRecords(t *testing.T) {
+	provider := newTestTencentProvider(t, map[string]string{
+		"example.com": `{"Response":{"RecordCountInfo":{"TotalCount":1},"RecordList":[{"Name":"www","Type":"A","Value":"192.0.2.1","Status":"ENABLE","Remark":"官网"}],"RequestId":"1"}}`,
+		"empty.com":   `{"Response":{"Error":{"Code":"ResourceNotFound.NoDataOfRecord","Message":"记录列表为空。"},"RequestId":"2"}}`,
+		"denied.com":  `{"Response":{"Error":{"Code":"AuthFailure.SignatureFailure","Message":"签名错误"},"RequestId":"3"}}`,
+	})
+
+	records, err := provider.DescribeDomainRecords("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := DomainRecord{Provider: "tencent", Zone: "example.com", RR: "www", Type: "A", Value: "192.0.2.1", Status: "ENABLE", Remark: "官网"}
+	if len(records) != 1 || records[0].Host() != want.Host() || records[0].Value != want.Value || records[0].Remark != want.Remark {
+		t.Fatalf("records = %+v, want %+v", records, want)
+	}
+
+	records, err = provider.DescribeDomainRecords("empty.com")
+	if err != nil || len(records) != 0 {
+		t.Fatalf("空域名返回 %+v, %v", records, err)
+	}
+
+	if _, err = provider.DescribeDomainRecords("denied.com"); err == nil || !strings.Contains(err.Error(), "AuthFailure.SignatureFailure") {
+		t.Fatalf("鉴权失败时返回 %v", err)
+	}
+}
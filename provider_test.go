@@ -0,0 +1,164 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+const testLegacyCloudConfig = `
+cloud:
+  alibaba:
+    aliyun_key: "ak"
+    aliyun_secret: "sk"
+    region: "cn-shenzhen"
+  tencent:
+    tencent_key: "id"
+    tencent_secret: "key"
+`
+
+/**
+* 旧版config.yml没有providers时，cloud.alibaba和cloud.tencent映射成aliyun和tencent服务商
+ * @param t
+*/
+func TestLoadProviderConfigsLegacyCloud(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.SetConfigType("yaml")
+	if err := viper.ReadConfig(strings.NewReader(testLegacyCloudConfig)); err != nil {
+		t.Fatal(err)
+	}
+
+	confs, err := LoadProviderConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ProviderConfig{
+		{Name: "aliyun", Type: "aliyun", Title: "阿里云", Options: map[string]interface{}{
+			"aliyun_key": "ak", "aliyun_secret": "sk", "region": "cn-shenzhen",
+		}},
+		{Name: "tencent", Type: "tencent", Title: "腾讯云", Options: map[string]interface{}{
+			"tencent_key": "id", "tencent_secret": "key",
+		}},
+	}
+	if !reflect.DeepEqual(confs, want) {
+		t.Fatalf("LoadProviderConfigs = %+v, want %+v", confs, want)
+	}
+}
+
+/**
+* 同时存在providers和旧版cloud配置时只使用providers
+ * @param t
+*/
+func TestLoadProviderConfigsPrefersProviders(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.SetConfigType("yaml")
+	content := testLegacyCloudConfig + "providers:\n  - name: cf\n    type: cloudflare\n    api_token: token\n"
+	if err := viper.ReadConfig(strings.NewReader(content)); err != nil {
+		t.Fatal(err)
+	}
+
+	confs, err := LoadProviderConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(confs) != 1 || confs[0].Name != "cf" || confs[0].Type != "cloudflare" || confs[0].Title != "cf" || confs[0].GetString("api_token") != "token" {
+		t.Fatalf("LoadProviderConfigs = %+v", confs)
+	}
+}
+
+/**
+* 没有配置任何服务商时返回错误，不能继续生成空的清单
+ * @param t
+*/
+func TestLoadProviderConfigsEmpty(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.SetConfigType("yaml")
+	if err := viper.ReadConfig(strings.NewReader("api:\n  wx_api: \"\"\n")); err != nil {
+		t.Fatal(err)
+	}
+	if confs, err := LoadProviderConfigs(); err == nil {
+		t.Fatalf("LoadProviderConfigs = %+v, want error", confs)
+	}
+
+	// providers为空列表同样视为没有配置
+	viper.Set("providers", []interface{}{})
+	if confs, err := LoadProviderConfigs(); err == nil {
+		t.Fatalf("LoadProviderConfigs = %+v, want error", confs)
+	}
+}
+
+/**
+* 解析记录对应的完整域名，@和空主机记录表示zone本身，静态清单没有zone时RR就是完整域名
+ * @param t
+*/
+func TestDomainRecordHost(t *testing.T) {
+	tests := []struct {
+		record DomainRecord
+		host   string
+		target string
+	}{
+		{DomainRecord{Zone: "example.com", RR: "@"}, "example.com", "example.com"},
+		{DomainRecord{Zone: "example.com", RR: ""}, "example.com", "example.com"},
+		{DomainRecord{Zone: "example.com", RR: "www"}, "www.example.com", "www.example.com"},
+		{DomainRecord{Zone: "example.com", RR: "*"}, "*.example.com", "*.example.com"},
+		{DomainRecord{Zone: "example.com", RR: "@", Port: 8443}, "example.com", "example.com:8443"},
+		{DomainRecord{RR: "intranet.example.com", Port: 443}, "intranet.example.com", "intranet.example.com"},
+	}
+	for _, test := range tests {
+		if got := test.record.Host(); got != test.host {
+			t.Errorf("Host(%+v) = %s, want %s", test.record, got, test.host)
+		}
+		if got := test.record.Target(); got != test.target {
+			t.Errorf("Target(%+v) = %s, want %s", test.record, got, test.target)
+		}
+	}
+}
+
+/**
+* 服务商返回的完整记录名转换成主机记录，zone本身为@
+ * @param t
+*/
+func TestRelativeName(t *testing.T) {
+	tests := []struct {
+		fqdn string
+		want string
+	}{
+		{"example.com.", "@"},
+		{"Example.COM", "@"},
+		{"www.example.com.", "www"},
+		{"*.api.example.com.", "*.api"},
+	}
+	for _, test := range tests {
+		if got := relativeName(test.fqdn, "example.com."); got != test.want {
+			t.Errorf("relativeName(%s) = %s, want %s", test.fqdn, got, test.want)
+		}
+	}
+}
+
+/**
+* 服务商name重复时返回错误，避免域名列表和执行状态互相覆盖
+ * @param t
+*/
+func TestLoadProviderConfigsDuplicateName(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.SetConfigType("yaml")
+	content := "providers:\n  - name: aliyun\n    aliyun_key: a\n  - name: tencent\n  - name: aliyun\n    type: aliyun\n    aliyun_key: b\n"
+	if err := viper.ReadConfig(strings.NewReader(content)); err != nil {
+		t.Fatal(err)
+	}
+
+	confs, err := LoadProviderConfigs()
+	if err == nil || !strings.Contains(err.Error(), "providers第3项的name aliyun与第1项重复") {
+		t.Fatalf("LoadProviderConfigs = %+v, %v, want 重复错误", confs, err)
+	}
+}
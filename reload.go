@@ -0,0 +1,399 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Reload后的校验方式
+const (
+	ReloadVerifyNone   = "none"
+	ReloadVerifyMetric = "metric"
+	ReloadVerifyConfig = "config"
+)
+
+// 默认的Reload结果指标，VictoriaMetrics(vmagent)为vm_promscrape_config_last_reload_successful
+const defaultReloadMetric = "prometheus_config_last_reload_successful"
+
+// 定义一个需要Reload的Prometheus/VictoriaMetrics实例，对应config.yml中prometheus.instances的一项
+type ReloadInstance struct {
+	Name            string            `mapstructure:"name"`
+	ReloadURL       string            `mapstructure:"reload_url"`
+	Method          string            `mapstructure:"method"`
+	Verify          string            `mapstructure:"verify"`
+	VerifyURL       string            `mapstructure:"verify_url"`
+	VerifyMetric    string            `mapstructure:"verify_metric"`
+	Timeout         int               `mapstructure:"timeout"`
+	BasicAuth       *ReloadBasicAuth  `mapstructure:"basic_auth"`
+	BearerToken     string            `mapstructure:"bearer_token"`
+	BearerTokenFile string            `mapstructure:"bearer_token_file"`
+	TLS             ReloadTLSConfig   `mapstructure:"tls"`
+	Headers         map[string]string `mapstructure:"headers"`
+}
+
+// 定义basic认证
+type ReloadBasicAuth struct {
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+// 定义HTTPS和mTLS配置，cert_file和key_file用于客户端证书认证
+type ReloadTLSConfig struct {
+	CAFile             string `mapstructure:"ca_file"`
+	CertFile           string `mapstructure:"cert_file"`
+	KeyFile            string `mapstructure:"key_file"`
+	ServerName         string `mapstructure:"server_name"`
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"`
+}
+
+// /api/v1/status/config接口的出参
+type prometheusConfigStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		YAML string `json:"yaml"`
+	} `json:"data"`
+}
+
+/**
+* 读取prometheus.instances配置，没有配置时兼容原来的api.prometheus_api
+ * @return []ReloadInstance
+ * @return error
+*/
+func LoadReloadInstances() (instances []ReloadInstance, _err error) {
+	if viper.IsSet("prometheus.instances") {
+		if _err = viper.UnmarshalKey("prometheus.instances", &instances); _err != nil {
+			return nil, _err
+		}
+	} else if reloadURL := viper.GetString("api.prometheus_api"); reloadURL != "" {
+		instances = []ReloadInstance{{ReloadURL: reloadURL}}
+	}
+
+	for i := range instances {
+		instance := &instances[i]
+		if instance.ReloadURL == "" {
+			return nil, fmt.Errorf("prometheus.instances第%d项没有配置reload_url", i+1)
+		}
+		if instance.Name == "" {
+			instance.Name = instance.ReloadURL
+		}
+		if instance.Method == "" {
+			instance.Method = http.MethodPost
+		}
+		if instance.Verify == "" {
+			instance.Verify = ReloadVerifyMetric
+		}
+		if instance.VerifyMetric == "" {
+			instance.VerifyMetric = defaultReloadMetric
+		}
+		if instance.Timeout <= 0 {
+			instance.Timeout = 10
+		}
+		switch instance.Verify {
+		case ReloadVerifyNone, ReloadVerifyMetric, ReloadVerifyConfig:
+		default:
+			return nil, fmt.Errorf("%s的verify不支持%s，可选none、metric、config", instance.Name, instance.Verify)
+		}
+	}
+	return instances, nil
+}
+
+/**
+* 调用所有实例的Reload接口并校验结果，任意一个实例失败都返回错误，但会继续处理其它实例
+ * @return error
+*/
+func ReloadPrometheus() (_err error) {
+	instances, _err := LoadReloadInstances()
+	if _err != nil {
+		errlogger.Printf("读取prometheus.instances配置异常: %v", _err)
+		return _err
+	}
+	// 使用HTTP服务发现时不需要Reload，可以不配置
+	if len(instances) == 0 {
+		infologger.Printf("没有配置需要Reload的Prometheus实例，跳过Reload")
+		return nil
+	}
+
+	var failed []string
+	for _, instance := range instances {
+		if err := instance.Reload(); err != nil {
+			errlogger.Printf("Reload %s:执行失败: %v", instance.Name, err)
+			failed = append(failed, fmt.Sprintf("%s: %v", instance.Name, err))
+			continue
+		}
+		infologger.Printf("Reload %s:执行完成", instance.Name)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d/%d个实例Reload失败: %s", len(failed), len(instances), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+/**
+* 调用实例的Reload接口，检查状态码，再按verify配置确认新配置已经生效
+ * @return error
+*/
+func (instance ReloadInstance) Reload() (_err error) {
+	client, _err := instance.httpClient()
+	if _err != nil {
+		return _err
+	}
+
+	// 先记下实例上一次成功Reload的时间，Reload后要求该时间增加，不比较本机和实例的时钟
+	var before float64
+	var hasBefore bool
+	if instance.Verify == ReloadVerifyMetric {
+		before, hasBefore = instance.lastReloadTimestamp(client)
+	}
+
+	body, _err := instance.request(client, instance.Method, instance.ReloadURL)
+	infologger.Printf("调用%s Reload接口出参: %s", instance.Name, string(body))
+	if _err != nil {
+		return _err
+	}
+
+	switch instance.Verify {
+	case ReloadVerifyMetric:
+		return instance.verifyMetric(client, before, hasBefore)
+	case ReloadVerifyConfig:
+		return instance.verifyConfig(client)
+	}
+	return nil
+}
+
+/**
+* Reload结果指标对应的时间指标，prometheus_config_last_reload_successful对应prometheus_config_last_reload_success_timestamp_seconds，VictoriaMetrics同样以_timestamp_seconds结尾
+ * @return string
+*/
+func (instance ReloadInstance) timestampMetric() string {
+	return strings.TrimSuffix(instance.VerifyMetric, "_successful") + "_success_timestamp_seconds"
+}
+
+/**
+* 获取实例最近一次成功Reload的时间，获取失败或没有时间指标时返回false，此时只校验Reload结果指标
+ * @param client
+ * @return float64
+ * @return bool
+*/
+func (instance ReloadInstance) lastReloadTimestamp(client *http.Client) (float64, bool) {
+	body, err := instance.request(client, http.MethodGet, instance.verifyURL("/metrics"))
+	if err != nil {
+		errlogger.Printf("Reload前获取%s的%s异常: %v", instance.Name, instance.timestampMetric(), err)
+		return 0, false
+	}
+	return metricValue(body, instance.timestampMetric())
+}
+
+/**
+* 通过实例自身的/metrics确认最近一次Reload成功，Reload前取到了时间指标时同时确认时间有增加(是本次Reload)
+ * @param client
+ * @param before
+ * @param hasBefore
+ * @return error
+*/
+func (instance ReloadInstance) verifyMetric(client *http.Client, before float64, hasBefore bool) (_err error) {
+	body, _err := instance.request(client, http.MethodGet, instance.verifyURL("/metrics"))
+	if _err != nil {
+		return fmt.Errorf("获取Reload结果指标异常: %v", _err)
+	}
+
+	value, ok := metricValue(body, instance.VerifyMetric)
+	if !ok {
+		return fmt.Errorf("没有找到Reload结果指标%s", instance.VerifyMetric)
+	}
+	if value != 1 {
+		return fmt.Errorf("新配置加载失败(%s=%v)", instance.VerifyMetric, value)
+	}
+
+	if !hasBefore {
+		return nil
+	}
+	timestampMetric := instance.timestampMetric()
+	if timestamp, ok := metricValue(body, timestampMetric); ok && timestamp <= before {
+		return fmt.Errorf("最近一次成功Reload的时间没有变化，本次Reload没有生效(%s=%v)", timestampMetric, timestamp)
+	}
+	return nil
+}
+
+/**
+* 通过/api/v1/status/config确认实例当前加载的配置，配置中没有引用targets.file时记录警告
+ * @param client
+ * @return error
+*/
+func (instance ReloadInstance) verifyConfig(client *http.Client) (_err error) {
+	body, _err := instance.request(client, http.MethodGet, instance.verifyURL("/api/v1/status/config"))
+	if _err != nil {
+		return fmt.Errorf("获取当前配置异常: %v", _err)
+	}
+
+	var status prometheusConfigStatus
+	if _err = json.Unmarshal(body, &status); _err != nil {
+		return fmt.Errorf("解析当前配置异常: %v", _err)
+	}
+	if status.Status != "success" || status.Data.YAML == "" {
+		return fmt.Errorf("获取当前配置失败: %s", status.Error)
+	}
+
+	if targetsFile := viper.GetString("targets.file"); targetsFile != "" && !strings.Contains(status.Data.YAML, filepath.Base(targetsFile)) {
+		errlogger.Printf("%s当前配置中没有引用%s，请确认file_sd_configs配置", instance.Name, filepath.Base(targetsFile))
+	}
+	return nil
+}
+
+/**
+* 获取校验地址，没有配置verify_url时使用reload_url所在服务的路径
+ * @param path
+ * @return string
+*/
+func (instance ReloadInstance) verifyURL(path string) string {
+	if instance.VerifyURL != "" {
+		return instance.VerifyURL
+	}
+
+	u, err := url.Parse(instance.ReloadURL)
+	if err != nil {
+		return instance.ReloadURL
+	}
+	// 带路径前缀(--web.route-prefix)时保留前缀
+	u.Path = strings.TrimSuffix(u.Path, "/-/reload") + path
+	u.RawQuery = ""
+	return u.String()
+}
+
+/**
+* 发送带认证信息的请求，非2xx状态码返回错误
+ * @param client
+ * @param method
+ * @param requestURL
+ * @return []byte
+ * @return error
+*/
+func (instance ReloadInstance) request(client *http.Client, method string, requestURL string) (body []byte, _err error) {
+	req, _err := http.NewRequest(method, requestURL, nil)
+	if _err != nil {
+		return nil, _err
+	}
+	for key, value := range instance.Headers {
+		req.Header.Set(key, value)
+	}
+
+	// 认证信息
+	if instance.BasicAuth != nil {
+		req.SetBasicAuth(instance.BasicAuth.Username, instance.BasicAuth.Password)
+	}
+	token := instance.BearerToken
+	if instance.BearerTokenFile != "" {
+		content, _err := ioutil.ReadFile(instance.BearerTokenFile)
+		if _err != nil {
+			return nil, _err
+		}
+		token = strings.TrimSpace(string(content))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, _err := client.Do(req)
+	if _err != nil {
+		return nil, _err
+	}
+	defer resp.Body.Close()
+
+	body, _err = ioutil.ReadAll(resp.Body)
+	if _err != nil {
+		return nil, _err
+	}
+	infologger.Printf("调用%s %s状态码: %s", method, requestURL, resp.Status)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return body, fmt.Errorf("%s %s返回状态码%s: %s", method, requestURL, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+/**
+* 根据tls配置创建HTTP客户端
+ * @return *http.Client
+ * @return error
+*/
+func (instance ReloadInstance) httpClient() (client *http.Client, _err error) {
+	tlsConfig := &tls.Config{
+		ServerName:         instance.TLS.ServerName,
+		InsecureSkipVerify: instance.TLS.InsecureSkipVerify,
+	}
+	if instance.TLS.CAFile != "" {
+		content, _err := ioutil.ReadFile(instance.TLS.CAFile)
+		if _err != nil {
+			return nil, _err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(content) {
+			return nil, errors.New("CA文件中没有可用的PEM证书: " + instance.TLS.CAFile)
+		}
+	}
+	if instance.TLS.CertFile != "" || instance.TLS.KeyFile != "" {
+		cert, _err := tls.LoadX509KeyPair(instance.TLS.CertFile, instance.TLS.KeyFile)
+		if _err != nil {
+			return nil, fmt.Errorf("加载客户端证书异常: %v", _err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return &http.Client{
+		Timeout:   time.Duration(instance.Timeout) * time.Second,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment},
+	}, nil
+}
+
+/**
+* 从Prometheus文本格式的指标中取出某个指标的值，有多条时取第一条
+ * @param body
+ * @param name
+ * @return float64
+ * @return bool
+*/
+func metricValue(body []byte, name string) (float64, bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, name) {
+			continue
+		}
+		rest := line[len(name):]
+		if !strings.HasPrefix(rest, " ") && !strings.HasPrefix(rest, "{") {
+			continue
+		}
+		// 去掉标签部分，剩下的是值和可选的时间戳
+		if i := strings.LastIndex(rest, "}"); i >= 0 {
+			rest = rest[i+1:]
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			continue
+		}
+		return value, true
+	}
+	return 0, false
+}
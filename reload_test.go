@@ -0,0 +1,172 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// 测试用Prometheus实例，时钟与本机不一致，Reload时间从2001年开始
+type fakePrometheus struct {
+	mutex      sync.Mutex
+	successful int
+	timestamp  int64
+	// Reload接口的处理方式：ok更新时间，fail加载失败，ignore返回200但没有重新加载
+	mode   string
+	config string
+	token  string
+}
+
+/**
+* 启动测试用Prometheus实例，请求没有带Bearer token时返回401
+ * @param t
+ * @param prometheus
+ * @param tls
+ * @return *httptest.Server
+*/
+func startFakePrometheus(t *testing.T, prometheus *fakePrometheus, tls bool) *httptest.Server {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prometheus.mutex.Lock()
+		defer prometheus.mutex.Unlock()
+		if r.Header.Get("Authorization") != "Bearer "+prometheus.token {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/-/reload":
+			if r.Method != http.MethodPost {
+				http.Error(w, "Only POST or PUT requests allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			switch prometheus.mode {
+			case "ok":
+				prometheus.successful = 1
+				prometheus.timestamp += 60
+			case "fail":
+				prometheus.successful = 0
+				http.Error(w, "failed to reload config", http.StatusInternalServerError)
+				return
+			}
+		case "/metrics":
+			fmt.Fprintf(w, "# TYPE prometheus_config_last_reload_successful gauge\nprometheus_config_last_reload_successful %d\n", prometheus.successful)
+			fmt.Fprintf(w, "prometheus_config_last_reload_success_timestamp_seconds %d\n", prometheus.timestamp)
+		case "/api/v1/status/config":
+			fmt.Fprintf(w, `{"status":"success","data":{"yaml":%q}}`, prometheus.config)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	server := httptest.NewUnstartedServer(handler)
+	// 不信任测试证书的握手失败是预期的，不输出日志
+	server.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	if tls {
+		server.StartTLS()
+	} else {
+		server.Start()
+	}
+	t.Cleanup(server.Close)
+	return server
+}
+
+/**
+* 通过/metrics校验时只要求Reload时间增加，实例时钟比本机慢很多也不会误判
+ * @param t
+*/
+func TestReloadVerifyMetric(t *testing.T) {
+	prometheus := &fakePrometheus{successful: 1, timestamp: 1000000000, mode: "ok", token: "secret-token"}
+	server := startFakePrometheus(t, prometheus, false)
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := ioutil.WriteFile(tokenFile, []byte("secret-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	instance := ReloadInstance{Name: "prometheus", ReloadURL: server.URL + "/-/reload", Method: http.MethodPost, Verify: ReloadVerifyMetric, VerifyMetric: defaultReloadMetric, Timeout: 5, BearerTokenFile: tokenFile}
+
+	if err := instance.Reload(); err != nil {
+		t.Fatalf("Reload成功时返回错误: %v", err)
+	}
+
+	// 返回200但没有重新加载，时间没有变化
+	prometheus.mode = "ignore"
+	if err := instance.Reload(); err == nil || !strings.Contains(err.Error(), "没有变化") {
+		t.Fatalf("Reload没有生效时返回 %v", err)
+	}
+
+	// 新配置加载失败，Reload接口返回500
+	prometheus.mode = "fail"
+	if err := instance.Reload(); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Fatalf("Reload接口返回500时返回 %v", err)
+	}
+	if err := instance.verifyMetric(&http.Client{}, 0, false); err == nil || !strings.Contains(err.Error(), "新配置加载失败") {
+		t.Fatalf("加载失败后校验返回 %v", err)
+	}
+
+	// token错误时Reload接口返回401
+	instance.BearerTokenFile = ""
+	instance.BearerToken = "wrong"
+	prometheus.mode = "ok"
+	if err := instance.Reload(); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("token错误时返回 %v", err)
+	}
+}
+
+/**
+* 通过/api/v1/status/config校验，HTTPS实例使用ca_file校验服务端证书
+ * @param t
+*/
+func TestReloadVerifyConfigTLS(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.Set("targets.file", "/etc/prometheus/aliyun-tencent-httpsdomain.yml")
+
+	prometheus := &fakePrometheus{successful: 1, timestamp: 1000000000, mode: "ok", token: "secret-token", config: "scrape_configs: []"}
+	server := startFakePrometheus(t, prometheus, true)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	instance := ReloadInstance{Name: "prometheus", ReloadURL: server.URL + "/-/reload", Method: http.MethodPost, Verify: ReloadVerifyConfig, Timeout: 5, BearerToken: "secret-token"}
+
+	// 没有配置ca_file时不信任测试证书
+	if err := instance.Reload(); err == nil || !strings.Contains(err.Error(), "certificate") {
+		t.Fatalf("没有配置ca_file时返回 %v", err)
+	}
+
+	instance.TLS = ReloadTLSConfig{CAFile: caFile, ServerName: "example.com"}
+	if err := instance.Reload(); err != nil {
+		t.Fatalf("配置ca_file后返回 %v", err)
+	}
+
+	// 当前配置获取失败
+	instance.VerifyURL = server.URL + "/api/v1/status/missing"
+	if err := instance.Reload(); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("获取当前配置失败时返回 %v", err)
+	}
+}
+
+/**
+* 没有配置verify_url时按reload_url推导校验地址，保留路径前缀
+ * @param t
+*/
+func TestReloadVerifyURL(t *testing.T) {
+	instance := ReloadInstance{ReloadURL: "http://127.0.0.1:9090/prometheus/-/reload?x=1"}
+	if got := instance.verifyURL("/metrics"); got != "http://127.0.0.1:9090/prometheus/metrics" {
+		t.Fatalf("verifyURL = %s", got)
+	}
+	instance.VerifyURL = "http://127.0.0.1:8429/metrics"
+	if got := instance.verifyURL("/metrics"); got != instance.VerifyURL {
+		t.Fatalf("verifyURL = %s, want %s", got, instance.VerifyURL)
+	}
+}
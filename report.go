@@ -0,0 +1,240 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"crypto/tls"
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// 定义单个目标一次探测的结构化结果，探测失败的目标也会记录，用于生成JSON/CSV报表
+type ProbeResult struct {
+	Target        string            `json:"target"`
+	Host          string            `json:"host"`
+	Port          string            `json:"port"`
+	IPs           []string          `json:"ips"`
+	Provider      string            `json:"provider"`
+	Zone          string            `json:"zone"`
+	Issuer        string            `json:"issuer"`
+	Subject       string            `json:"subject"`
+	SANs          []string          `json:"sans"`
+	Serial        string            `json:"serial"`
+	NotBefore     *time.Time        `json:"not_before"`
+	NotAfter      *time.Time        `json:"not_after"`
+	DaysLeft      *int              `json:"days_left"`
+	ExpiryLevel   string            `json:"expiry_level"`
+	TLSVersion    string            `json:"tls_version"`
+	Cipher        string            `json:"cipher"`
+	ErrorCategory string            `json:"error_category"`
+	Error         string            `json:"error"`
+	ChainProblems []string          `json:"chain_problems"`
+	Issues        []string          `json:"issues"`
+	LatencyMs     int64             `json:"latency_ms"`  // 连接和握手的耗时，不包含限流等待和重试
+	DurationMs    int64             `json:"duration_ms"` // 整个探测的耗时，包括限流等待和重试
+	Labels        map[string]string `json:"labels"`
+	CheckedAt     time.Time         `json:"checked_at"`
+}
+
+// CSV报表的表头，与WriteCSVReport中每一列的顺序对应
+var reportCSVHeader = []string{
+	"target", "host", "port", "ips", "provider", "zone", "issuer", "subject", "sans", "serial",
+	"not_before", "not_after", "days_left", "expiry_level", "tls_version", "cipher",
+	"error_category", "error", "chain_problems", "issues", "latency_ms", "duration_ms", "labels", "checked_at",
+}
+
+/**
+* 根据探测结果生成结构化结果，state中没有证书时只记录目标、错误和耗时
+ * @param target
+ * @param host
+ * @param port
+ * @param outcome
+ * @param err
+ * @param duration 整个探测的耗时
+ * @param checkedAt
+ * @return ProbeResult
+*/
+func newProbeResult(target string, host string, port string, outcome probeOutcome, err error, duration time.Duration, checkedAt time.Time) ProbeResult {
+	result := ProbeResult{
+		Target:     target,
+		Host:       host,
+		Port:       port,
+		IPs:        outcome.IPs,
+		Issues:     outcome.Issues,
+		LatencyMs:  outcome.Latency.Milliseconds(),
+		DurationMs: duration.Milliseconds(),
+		CheckedAt:  checkedAt,
+	}
+
+	// 服务商、域名和标签来自域名清单
+	if i, ok := recordIndex[target]; ok {
+		result.Provider = recordSlice[i].Provider
+		result.Zone = recordSlice[i].Zone
+		result.Labels = recordSlice[i].Labels
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+		result.ErrorCategory = probeErrorCategory(err)
+	}
+
+	state := outcome.State
+	if len(state.PeerCertificates) == 0 {
+		return result
+	}
+
+	cert := state.PeerCertificates[0]
+	notBefore, notAfter := cert.NotBefore, cert.NotAfter
+	// 多个地址证书不一致时到期时间取最早的，与到期分级一致
+	if !outcome.NotAfter.IsZero() {
+		notAfter = outcome.NotAfter
+	}
+	result.Issuer = cert.Issuer.String()
+	result.Subject = cert.Subject.String()
+	result.SANs = cert.DNSNames
+	result.Serial = cert.SerialNumber.Text(16)
+	result.NotBefore = &notBefore
+	result.NotAfter = &notAfter
+	result.TLSVersion = tls.VersionName(state.Version)
+	result.Cipher = tls.CipherSuiteName(state.CipherSuite)
+	return result
+}
+
+/**
+* 把探测结果写入JSON报表，按目标排序
+ * @param path
+ * @param results
+ * @return error
+*/
+func WriteJSONReport(path string, results []ProbeResult) (_err error) {
+	content, _err := json.MarshalIndent(sortedProbeResults(results), "", "  ")
+	if _err != nil {
+		return _err
+	}
+	return ioutil.WriteFile(path, content, 0644)
+}
+
+/**
+* 把探测结果写入CSV报表，按目标排序，多值字段用分号分隔，标签格式为k1=v1;k2=v2
+ * @param path
+ * @param results
+ * @return error
+*/
+func WriteCSVReport(path string, results []ProbeResult) (_err error) {
+	file, _err := os.OpenFile(path, os.O_TRUNC|os.O_CREATE|os.O_WRONLY, 0644)
+	if _err != nil {
+		return _err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if _err = writer.Write(reportCSVHeader); _err != nil {
+		return _err
+	}
+	for _, result := range sortedProbeResults(results) {
+		var notBefore, notAfter, daysLeft string
+		if result.NotBefore != nil {
+			notBefore = result.NotBefore.Format(time.RFC3339)
+		}
+		if result.NotAfter != nil {
+			notAfter = result.NotAfter.Format(time.RFC3339)
+		}
+		if result.DaysLeft != nil {
+			daysLeft = strconv.Itoa(*result.DaysLeft)
+		}
+
+		_err = writer.Write([]string{
+			result.Target,
+			result.Host,
+			result.Port,
+			strings.Join(result.IPs, ";"),
+			result.Provider,
+			result.Zone,
+			result.Issuer,
+			result.Subject,
+			strings.Join(result.SANs, ";"),
+			result.Serial,
+			notBefore,
+			notAfter,
+			daysLeft,
+			result.ExpiryLevel,
+			result.TLSVersion,
+			result.Cipher,
+			result.ErrorCategory,
+			result.Error,
+			strings.Join(result.ChainProblems, ";"),
+			strings.Join(result.Issues, ";"),
+			strconv.FormatInt(result.LatencyMs, 10),
+			strconv.FormatInt(result.DurationMs, 10),
+			formatLabels(result.Labels),
+			result.CheckedAt.Format(time.RFC3339),
+		})
+		if _err != nil {
+			return _err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+/**
+* 按目标排序，并发探测时结果顺序不固定，排序后每次生成的报表便于对比
+ * @param results
+ * @return []ProbeResult
+*/
+func sortedProbeResults(results []ProbeResult) []ProbeResult {
+	sorted := append([]ProbeResult(nil), results...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Target < sorted[j].Target
+	})
+	return sorted
+}
+
+/**
+* 标签转换成k1=v1;k2=v2格式，与静态清单CSV中的标签格式一致
+ * @param labels
+ * @return string
+*/
+func formatLabels(labels map[string]string) string {
+	var keys []string
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var pairs []string
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+labels[key])
+	}
+	return strings.Join(pairs, ";")
+}
+
+/**
+* 按report配置写入JSON/CSV报表，没有配置路径的格式不生成
+ * @param results
+*/
+func writeReports(results []ProbeResult) {
+	if path := viper.GetString("report.json"); path != "" {
+		if err := WriteJSONReport(path, results); err != nil {
+			errlogger.Printf("写入JSON报表%s异常: %v", path, err)
+		} else {
+			infologger.Printf("写入JSON报表%s:执行完成", path)
+		}
+	}
+	if path := viper.GetString("report.csv"); path != "" {
+		if err := WriteCSVReport(path, results); err != nil {
+			errlogger.Printf("写入CSV报表%s异常: %v", path, err)
+		} else {
+			infologger.Printf("写入CSV报表%s:执行完成", path)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"os"
+	"syscall"
+)
+
+/**
+* 根据连接、握手阶段的原始错误判断失败分类，区分不出来的归为connect_error/tls_error
+ * @param err
+ * @return string
+*/
+func classifyProbeError(err error) string {
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	var recordErr tls.RecordHeaderError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &dnsErr):
+		if dnsErr.IsNotFound {
+			return ProbeErrDNSNotFound
+		}
+		if dnsErr.IsTimeout {
+			return ProbeErrTimeout
+		}
+		return ProbeErrDNS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ProbeErrRefused
+	case errors.Is(err, os.ErrDeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ProbeErrTimeout
+	case errors.As(err, &opErr) && opErr.Op == "remote error":
+		// 对端发送了TLS告警，通常是不支持SNI中的域名或者协议版本、加密套件不匹配
+		return ProbeErrTLSAlert
+	case errors.As(err, &recordErr):
+		// 对端返回的不是TLS数据，通常是端口上跑的是HTTP
+		return ProbeErrNotTLS
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		return ProbeErrConnect
+	}
+	return ProbeErrTLS
+}
+
+/**
+* 是否是可以重试的临时性失败，域名不存在、TLS告警、端口不是TLS、证书问题重试也不会恢复
+ * @param err
+ * @return bool
+*/
+func isRetryableProbeError(err error) bool {
+	switch probeErrorCategory(err) {
+	case ProbeErrDNS, ProbeErrRefused, ProbeErrTimeout, ProbeErrConnect, ProbeErrTLS:
+		return true
+	}
+	return false
+}
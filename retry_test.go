@@ -0,0 +1,209 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+/**
+* 连接、握手阶段的原始错误对应的失败分类
+ * @param t
+*/
+func TestClassifyProbeError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nxdomain", &net.DNSError{Err: "no such host", Name: "missing.example.com", IsNotFound: true}, ProbeErrDNSNotFound},
+		{"dns timeout", &net.DNSError{Err: "i/o timeout", Name: "www.example.com", IsTimeout: true}, ProbeErrTimeout},
+		{"dns server failure", &net.DNSError{Err: "server misbehaving", Name: "www.example.com"}, ProbeErrDNS},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, ProbeErrRefused},
+		{"dial timeout", &net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded}, ProbeErrTimeout},
+		{"handshake timeout", &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}, ProbeErrTimeout},
+		{"network unreachable", &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ENETUNREACH)}, ProbeErrConnect},
+		{"tls alert", &net.OpError{Op: "remote error", Err: errors.New("tls: handshake failure")}, ProbeErrTLSAlert},
+		{"not tls", tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}, ProbeErrNotTLS},
+		{"wrapped not tls", fmt.Errorf("握手失败: %w", tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}), ProbeErrNotTLS},
+		{"connection reset", errors.New("EOF"), ProbeErrTLS},
+	}
+	for _, test := range tests {
+		if got := classifyProbeError(test.err); got != test.want {
+			t.Errorf("%s: classifyProbeError = %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+/**
+* 只有临时性失败才重试，域名不存在、TLS告警、端口不是TLS、证书问题不重试
+ * @param t
+*/
+func TestIsRetryableProbeError(t *testing.T) {
+	tests := []struct {
+		category string
+		want     bool
+	}{
+		{ProbeErrDNSNotFound, false},
+		{ProbeErrDNS, true},
+		{ProbeErrRefused, true},
+		{ProbeErrTimeout, true},
+		{ProbeErrConnect, true},
+		{ProbeErrTLSAlert, false},
+		{ProbeErrNotTLS, false},
+		{ProbeErrTLS, true},
+		{ProbeErrNoCert, false},
+		{ProbeErrCertInvalid, false},
+	}
+	for _, test := range tests {
+		err := fmt.Errorf("所有IP探测失败(1个): %w", &ProbeError{Category: test.category, Message: "探测异常"})
+		if got := isRetryableProbeError(err); got != test.want {
+			t.Errorf("isRetryableProbeError(%s) = %v, want %v", test.category, got, test.want)
+		}
+	}
+
+	// 不是探测错误时不重试
+	if isRetryableProbeError(errors.New("unknown")) {
+		t.Error("isRetryableProbeError(非探测错误) = true, want false")
+	}
+}
+
+// 定义统计连接次数的监听器
+type countingListener struct {
+	net.Listener
+	accepts int64
+}
+
+/**
+* 接受连接并计数
+ * @return net.Conn
+ * @return error
+*/
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		atomic.AddInt64(&l.accepts, 1)
+	}
+	return conn, err
+}
+
+/**
+* 启动本地监听，每个连接交给handle处理，返回监听器和地址
+ * @param t
+ * @param wrap 为nil时使用TCP监听，否则用于包装成TLS监听
+ * @param handle
+ * @return *countingListener
+ * @return string
+*/
+func startCountingServer(t *testing.T, wrap func(net.Listener) net.Listener, handle func(net.Conn)) (*countingListener, string) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter := &countingListener{Listener: inner}
+	var listener net.Listener = counter
+	if wrap != nil {
+		listener = wrap(counter)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			handle(conn)
+			conn.Close()
+		}
+	}()
+	return counter, inner.Addr().String()
+}
+
+/**
+* 设置重试次数和第一次重试前的等待时间(毫秒)
+ * @param t
+ * @param retries
+ * @param backoff
+*/
+func setProbeRetry(t *testing.T, retries int, backoff int) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.Set("probe.retries", retries)
+	viper.Set("probe.retry_backoff", backoff)
+}
+
+/**
+* 临时性失败按probe.retries重试，每次等待时间翻倍，达到重试次数后返回最后一次的错误
+ * @param t
+*/
+func TestProbeTLSRetriesTransientFailure(t *testing.T) {
+	setProbeRetry(t, 2, 50)
+
+	// 接受连接后直接关闭，握手失败归为tls_error
+	counter, address := startCountingServer(t, nil, func(conn net.Conn) {})
+
+	start := time.Now()
+	_, _, _, err := probeTLS("tcp", address, "localhost")
+	elapsed := time.Since(start)
+	if got := probeErrorCategory(err); got != ProbeErrTLS {
+		t.Fatalf("失败分类 = %s(%v), want %s", got, err, ProbeErrTLS)
+	}
+	if accepts := atomic.LoadInt64(&counter.accepts); accepts != 3 {
+		t.Fatalf("连接次数 = %d, want 3(1次探测+2次重试)", accepts)
+	}
+	// 两次重试分别等待50ms和100ms
+	if elapsed < 150*time.Millisecond {
+		t.Fatalf("耗时%s，重试没有按backoff等待", elapsed)
+	}
+}
+
+/**
+* 端口不是TLS、证书校验失败时重试也不会恢复，只探测一次
+ * @param t
+*/
+func TestProbeTLSNoRetryOnPermanentFailure(t *testing.T) {
+	setProbeRetry(t, 3, 10)
+
+	// 端口上是HTTP服务
+	counter, address := startCountingServer(t, nil, func(conn net.Conn) {
+		conn.Write([]byte("HTTP/1.1 400 Bad Request\r\nContent-Length: 0\r\n\r\n"))
+	})
+	_, _, _, err := probeTLS("tcp", address, "localhost")
+	if got := probeErrorCategory(err); got != ProbeErrNotTLS {
+		t.Fatalf("失败分类 = %s(%v), want %s", got, err, ProbeErrNotTLS)
+	}
+	if accepts := atomic.LoadInt64(&counter.accepts); accepts != 1 {
+		t.Fatalf("端口不是TLS时连接次数 = %d, want 1", accepts)
+	}
+
+	// 证书不受信任
+	now := time.Now()
+	root := newTestCert(t, "Untrusted Root", nil, true, now.Add(-time.Hour), now.Add(24*time.Hour), nil)
+	leaf := newTestCert(t, "localhost", []string{"localhost"}, false, now.Add(-time.Hour), now.Add(24*time.Hour), &root)
+	certificate := tls.Certificate{Certificate: [][]byte{leaf.cert.Raw}, PrivateKey: leaf.key}
+	counter, address = startCountingServer(t, func(listener net.Listener) net.Listener {
+		return tls.NewListener(listener, &tls.Config{Certificates: []tls.Certificate{certificate}})
+	}, func(conn net.Conn) {
+		conn.(*tls.Conn).Handshake()
+	})
+	_, _, _, err = probeTLS("tcp", address, "localhost")
+	if got := probeErrorCategory(err); got != ProbeErrCertInvalid {
+		t.Fatalf("失败分类 = %s(%v), want %s", got, err, ProbeErrCertInvalid)
+	}
+	if accepts := atomic.LoadInt64(&counter.accepts); accepts != 1 {
+		t.Fatalf("证书校验失败时连接次数 = %d, want 1", accepts)
+	}
+}
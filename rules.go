@@ -0,0 +1,242 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// 定义Prometheus告警规则文件
+type RuleFile struct {
+	Groups []RuleGroup `yaml:"groups"`
+}
+
+// 定义一组告警规则
+type RuleGroup struct {
+	Name  string      `yaml:"name"`
+	Rules []AlertRule `yaml:"rules"`
+}
+
+// 定义一条告警规则
+type AlertRule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// 定义按团队覆盖的告警阈值，没有配置的项沿用全局配置
+type TeamRuleOverride struct {
+	Team            string `mapstructure:"team"`
+	WarningDays     int    `mapstructure:"warning_days"`
+	CriticalDays    int    `mapstructure:"critical_days"`
+	ProbeFailureFor string `mapstructure:"probe_failure_for"`
+}
+
+// 定义一套告警阈值，teamSelector为团队的PromQL标签选择器(不含大括号)，默认阈值为排除已覆盖团队的选择器
+type ruleThreshold struct {
+	teamSelector    string
+	warningDays     int
+	criticalDays    int
+	probeFailureFor string
+}
+
+/**
+* 读取rules.teams配置
+ * @return []TeamRuleOverride
+ * @return error
+*/
+func LoadTeamRuleOverrides() (overrides []TeamRuleOverride, _err error) {
+	if _err = viper.UnmarshalKey("rules.teams", &overrides); _err != nil {
+		return nil, _err
+	}
+	for _, override := range overrides {
+		if override.Team == "" {
+			return nil, fmt.Errorf("rules.teams中team不能为空: %+v", override)
+		}
+	}
+	return overrides, nil
+}
+
+/**
+* 生成证书到期、探测失败、证书链异常的告警规则，标签与targets文件中的标签一致(team由ownership生成)
+* exporterMetrics为true时证书到期和证书链规则使用常驻进程暴露的ssl_cert_*指标：握手时不校验证书，证书过期时也有到期时间，blackbox证书校验失败时不输出probe_ssl_earliest_cert_expiry
+* 为false时(单次执行或没有/metrics)证书到期规则改用blackbox的probe_ssl_earliest_cert_expiry，不生成证书链规则
+* 探测失败规则使用blackbox的probe_success；配置了团队阈值时，团队单独生成一套规则，默认规则排除这些团队
+ * @param overrides
+ * @param exporterMetrics
+ * @return RuleFile
+*/
+func buildAlertRules(overrides []TeamRuleOverride, exporterMetrics bool) RuleFile {
+	jobSelector := ""
+	if job := viper.GetString("rules.job"); job != "" {
+		jobSelector = fmt.Sprintf("job=%q", job)
+	}
+	metricsJobSelector := ""
+	if job := viper.GetString("rules.metrics_job"); job != "" {
+		metricsJobSelector = fmt.Sprintf("job=%q", job)
+	}
+	defaults := ruleThreshold{
+		warningDays:     viper.GetInt("expiry.warning_days"),
+		criticalDays:    viper.GetInt("expiry.critical_days"),
+		probeFailureFor: viper.GetString("rules.probe_failure_for"),
+	}
+
+	var thresholds []ruleThreshold
+	var teams []string
+	for _, override := range overrides {
+		threshold := defaults
+		threshold.teamSelector = fmt.Sprintf("%s=%q", OwnerLabelTeam, override.Team)
+		if override.WarningDays > 0 {
+			threshold.warningDays = override.WarningDays
+		}
+		if override.CriticalDays > 0 {
+			threshold.criticalDays = override.CriticalDays
+		}
+		if override.ProbeFailureFor != "" {
+			threshold.probeFailureFor = override.ProbeFailureFor
+		}
+		thresholds = append(thresholds, threshold)
+		teams = append(teams, regexp.QuoteMeta(override.Team))
+	}
+	if len(teams) > 0 {
+		defaults.teamSelector = fmt.Sprintf("%s!~%q", OwnerLabelTeam, strings.Join(teams, "|"))
+	}
+	thresholds = append([]ruleThreshold{defaults}, thresholds...)
+
+	expiryGroup := RuleGroup{Name: "httpsdomain-cert-expiry"}
+	probeGroup := RuleGroup{Name: "httpsdomain-probe"}
+	for _, threshold := range thresholds {
+		certSelector := joinSelectors(metricsJobSelector, threshold.teamSelector)
+		notAfter := withSelector("ssl_cert_not_after", certSelector)
+		hostLabel := "{{ $labels.host }}"
+		if !exporterMetrics {
+			notAfter = withSelector("probe_ssl_earliest_cert_expiry", joinSelectors(jobSelector, threshold.teamSelector))
+			hostLabel = "{{ $labels.instance }}"
+		}
+		days := fmt.Sprintf("(%s - time()) / 86400", notAfter)
+		expiryGroup.Rules = append(expiryGroup.Rules,
+			AlertRule{
+				Alert:  "SSLCertExpiringWarning",
+				Expr:   fmt.Sprintf("%s <= %d and %s > %d", days, threshold.warningDays, days, threshold.criticalDays),
+				For:    "10m",
+				Labels: map[string]string{"severity": CertLevelWarning},
+				Annotations: map[string]string{
+					"summary":     "证书即将到期: " + hostLabel,
+					"description": fmt.Sprintf("%s 证书剩余{{ $value | humanize }}天(告警阈值%d天)", hostLabel, threshold.warningDays),
+				},
+			},
+			AlertRule{
+				Alert:  "SSLCertExpiringCritical",
+				Expr:   fmt.Sprintf("%s <= %d and %s > time()", days, threshold.criticalDays, notAfter),
+				For:    "10m",
+				Labels: map[string]string{"severity": CertLevelCritical},
+				Annotations: map[string]string{
+					"summary":     "证书即将到期(紧急): " + hostLabel,
+					"description": fmt.Sprintf("%s 证书剩余{{ $value | humanize }}天(紧急阈值%d天)", hostLabel, threshold.criticalDays),
+				},
+			},
+			AlertRule{
+				Alert:  "SSLCertExpired",
+				Expr:   fmt.Sprintf("%s <= time()", notAfter),
+				Labels: map[string]string{"severity": CertLevelCritical},
+				Annotations: map[string]string{
+					"summary":     "证书已过期: " + hostLabel,
+					"description": hostLabel + " 证书已过期",
+				},
+			},
+		)
+		probeGroup.Rules = append(probeGroup.Rules, AlertRule{
+			Alert:  "HTTPSProbeFailed",
+			Expr:   withSelector("probe_success", joinSelectors(jobSelector, threshold.teamSelector)) + " == 0",
+			For:    threshold.probeFailureFor,
+			Labels: map[string]string{"severity": CertLevelCritical},
+			Annotations: map[string]string{
+				"summary":     "HTTPS探测失败: {{ $labels.instance }}",
+				"description": fmt.Sprintf("{{ $labels.instance }} blackbox探测失败超过%s", threshold.probeFailureFor),
+			},
+		})
+
+		// 证书链校验结果与证书到期一样按团队生成，指标中带有归属规则的team标签
+		if exporterMetrics && viper.GetBool("rules.chain_invalid") {
+			probeGroup.Rules = append(probeGroup.Rules, AlertRule{
+				Alert:  "SSLCertChainInvalid",
+				Expr:   withSelector("ssl_cert_chain_valid", certSelector) + " == 0",
+				For:    "10m",
+				Labels: map[string]string{"severity": CertLevelWarning},
+				Annotations: map[string]string{
+					"summary":     "证书链异常: {{ $labels.host }}",
+					"description": "{{ $labels.host }} 证书链校验失败(域名不匹配、缺少中间证书、根证书不受信任等)，详见通知或报表",
+				},
+			})
+		}
+	}
+
+	return RuleFile{Groups: []RuleGroup{expiryGroup, probeGroup}}
+}
+
+/**
+* 多个标签选择器用逗号连接，忽略空的选择器
+ * @param selectors
+ * @return string
+*/
+func joinSelectors(selectors ...string) string {
+	var parts []string
+	for _, selector := range selectors {
+		if selector != "" {
+			parts = append(parts, selector)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+/**
+* 给指标加上标签选择器
+ * @param metric
+ * @param selector
+ * @return string
+*/
+func withSelector(metric string, selector string) string {
+	if selector == "" {
+		return metric
+	}
+	return metric + "{" + selector + "}"
+}
+
+/**
+* 按rules配置生成告警规则文件，没有配置rules.file时不生成
+ * @return error
+*/
+func WriteAlertRules() (_err error) {
+	file := viper.GetString("rules.file")
+	if file == "" {
+		return nil
+	}
+
+	overrides, _err := LoadTeamRuleOverrides()
+	if _err != nil {
+		return _err
+	}
+
+	// ssl_cert_*指标只有常驻进程的/metrics才有，单次执行时这些规则永远不会触发
+	exporterMetrics := daemonMode && viper.GetString("metrics.listen") != ""
+	if !exporterMetrics {
+		errlogger.Printf("没有以-daemon运行或没有配置metrics.listen，证书到期规则改用blackbox的probe_ssl_earliest_cert_expiry(证书校验失败的目标没有该指标)")
+		if viper.GetBool("rules.chain_invalid") {
+			errlogger.Printf("没有以-daemon运行或没有配置metrics.listen，不生成证书链异常规则")
+		}
+	}
+	content, _err := yaml.Marshal(buildAlertRules(overrides, exporterMetrics))
+	if _err != nil {
+		return _err
+	}
+	return writeFileAtomic(file, content)
+}
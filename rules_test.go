@@ -0,0 +1,152 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+/**
+* 按告警名称和团队选择器查找规则
+ * @param file
+ * @param alert
+ * @param selector
+ * @return *AlertRule
+*/
+func findAlertRule(file RuleFile, alert string, expr string) *AlertRule {
+	for _, group := range file.Groups {
+		for i, rule := range group.Rules {
+			if rule.Alert == alert && rule.Expr == expr {
+				return &group.Rules[i]
+			}
+		}
+	}
+	return nil
+}
+
+/**
+* 证书到期和证书链规则使用ssl_cert_*指标并带团队选择器，探测失败规则使用blackbox指标
+ * @param t
+*/
+func TestBuildAlertRules(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.Set("expiry.warning_days", 30)
+	viper.Set("expiry.critical_days", 7)
+	viper.Set("rules.probe_failure_for", "5m")
+	viper.Set("rules.chain_invalid", true)
+	viper.Set("rules.job", "blackbox")
+	viper.Set("rules.metrics_job", "httpsdomain")
+
+	file := buildAlertRules([]TeamRuleOverride{{Team: "pay.ment", WarningDays: 45, CriticalDays: 14, ProbeFailureFor: "2m"}}, true)
+
+	exprs := map[string][]string{
+		"SSLCertExpiringWarning": {
+			`(ssl_cert_not_after{job="httpsdomain", team!~"pay\\.ment"} - time()) / 86400 <= 30 and (ssl_cert_not_after{job="httpsdomain", team!~"pay\\.ment"} - time()) / 86400 > 7`,
+			`(ssl_cert_not_after{job="httpsdomain", team="pay.ment"} - time()) / 86400 <= 45 and (ssl_cert_not_after{job="httpsdomain", team="pay.ment"} - time()) / 86400 > 14`,
+		},
+		"SSLCertExpiringCritical": {
+			`(ssl_cert_not_after{job="httpsdomain", team!~"pay\\.ment"} - time()) / 86400 <= 7 and ssl_cert_not_after{job="httpsdomain", team!~"pay\\.ment"} > time()`,
+			`(ssl_cert_not_after{job="httpsdomain", team="pay.ment"} - time()) / 86400 <= 14 and ssl_cert_not_after{job="httpsdomain", team="pay.ment"} > time()`,
+		},
+		"SSLCertExpired": {
+			`ssl_cert_not_after{job="httpsdomain", team!~"pay\\.ment"} <= time()`,
+			`ssl_cert_not_after{job="httpsdomain", team="pay.ment"} <= time()`,
+		},
+		"SSLCertChainInvalid": {
+			`ssl_cert_chain_valid{job="httpsdomain", team!~"pay\\.ment"} == 0`,
+			`ssl_cert_chain_valid{job="httpsdomain", team="pay.ment"} == 0`,
+		},
+		"HTTPSProbeFailed": {
+			`probe_success{job="blackbox", team!~"pay\\.ment"} == 0`,
+			`probe_success{job="blackbox", team="pay.ment"} == 0`,
+		},
+	}
+	total := 0
+	for alert, items := range exprs {
+		for _, expr := range items {
+			if findAlertRule(file, alert, expr) == nil {
+				t.Errorf("没有生成%s规则: %s", alert, expr)
+			}
+			total++
+		}
+	}
+	for _, group := range file.Groups {
+		total -= len(group.Rules)
+	}
+	if total != 0 {
+		t.Errorf("规则数量不一致: %+v", file)
+	}
+
+	if rule := findAlertRule(file, "HTTPSProbeFailed", `probe_success{job="blackbox", team="pay.ment"} == 0`); rule != nil && rule.For != "2m" {
+		t.Errorf("团队探测失败规则for = %s, want 2m", rule.For)
+	}
+}
+
+/**
+* 单次执行时没有ssl_cert_*指标，证书到期规则改用blackbox的probe_ssl_earliest_cert_expiry，不生成证书链规则
+ * @param t
+*/
+func TestWriteAlertRulesOneShot(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	file := filepath.Join(t.TempDir(), "rules.yml")
+	viper.Set("rules.file", file)
+	viper.Set("expiry.warning_days", 30)
+	viper.Set("expiry.critical_days", 7)
+	viper.Set("rules.probe_failure_for", "5m")
+	viper.Set("rules.chain_invalid", true)
+	viper.Set("rules.job", "blackbox")
+	viper.Set("rules.metrics_job", "httpsdomain")
+	viper.Set("metrics.listen", ":9219")
+
+	if err := WriteAlertRules(); err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rules RuleFile
+	if err := yaml.Unmarshal(content, &rules); err != nil {
+		t.Fatal(err)
+	}
+
+	exprs := map[string]string{
+		"SSLCertExpiringWarning":  `(probe_ssl_earliest_cert_expiry{job="blackbox"} - time()) / 86400 <= 30 and (probe_ssl_earliest_cert_expiry{job="blackbox"} - time()) / 86400 > 7`,
+		"SSLCertExpiringCritical": `(probe_ssl_earliest_cert_expiry{job="blackbox"} - time()) / 86400 <= 7 and probe_ssl_earliest_cert_expiry{job="blackbox"} > time()`,
+		"SSLCertExpired":          `probe_ssl_earliest_cert_expiry{job="blackbox"} <= time()`,
+		"HTTPSProbeFailed":        `probe_success{job="blackbox"} == 0`,
+	}
+	for alert, expr := range exprs {
+		rule := findAlertRule(rules, alert, expr)
+		if rule == nil {
+			t.Errorf("没有生成%s规则: %s", alert, expr)
+			continue
+		}
+		if summary := rule.Annotations["summary"]; summary != "" && !strings.Contains(summary, "$labels.instance") {
+			t.Errorf("%s规则summary = %s, want $labels.instance", alert, summary)
+		}
+	}
+	if strings.Contains(string(content), "ssl_cert_") || strings.Contains(string(content), "SSLCertChainInvalid") {
+		t.Errorf("单次执行生成了依赖/metrics的规则:\n%s", content)
+	}
+
+	// 常驻进程并配置了metrics.listen时使用ssl_cert_*指标
+	daemonMode = true
+	t.Cleanup(func() { daemonMode = false })
+	if err := WriteAlertRules(); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := ioutil.ReadFile(file); !strings.Contains(string(content), "SSLCertChainInvalid") || strings.Contains(string(content), "probe_ssl_earliest_cert_expiry") {
+		t.Errorf("常驻进程没有使用ssl_cert_*指标:\n%s", content)
+	}
+}
@@ -0,0 +1,164 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/viper"
+)
+
+// 最近一次探测生成的目标，供HTTP服务发现接口读取，nil表示还没有生成过
+var (
+	targetGroupsMutex   sync.RWMutex
+	currentTargetGroups []TargetGroup
+)
+
+/**
+* 更新HTTP服务发现接口返回的目标
+ * @param groups
+*/
+func setTargetGroups(groups []TargetGroup) {
+	if groups == nil {
+		groups = []TargetGroup{}
+	}
+	targetGroupsMutex.Lock()
+	defer targetGroupsMutex.Unlock()
+	currentTargetGroups = groups
+}
+
+/**
+* 启动时从上一次生成的targets文件加载目标，避免第一次探测完成前HTTP服务发现接口没有目标可返回
+ * @return error
+*/
+func seedTargetGroups() (_err error) {
+	file := viper.GetString("targets.file")
+	if file == "" {
+		return nil
+	}
+	if _, _err = os.Stat(file); os.IsNotExist(_err) {
+		return nil
+	}
+
+	groups, _err := ReadFileSD(file)
+	if _err != nil {
+		return _err
+	}
+	setTargetGroups(groups)
+	infologger.Printf("从%s加载目标%d组", file, len(groups))
+	return nil
+}
+
+/**
+* Prometheus http_sd_configs接口，返回格式与file_sd的JSON格式一致
+* 还没有生成过目标时返回503，Prometheus会保留上一次获取到的目标，不会因为空列表把目标全部删掉
+ * @param w
+ * @param r
+*/
+func httpSDHandler(w http.ResponseWriter, r *http.Request) {
+	targetGroupsMutex.RLock()
+	groups := currentTargetGroups
+	targetGroupsMutex.RUnlock()
+	if groups == nil {
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, "目标尚未生成，等待第一次探测完成", http.StatusServiceUnavailable)
+		return
+	}
+
+	content, err := json.Marshal(groups)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(content)
+}
+
+// 定义常驻进程的一个HTTP接口，key为配置项前缀(metrics、http_sd)
+type serverEndpoint struct {
+	key     string
+	listen  string
+	path    string
+	handler http.Handler
+}
+
+/**
+* 读取metrics和http_sd配置，listen为空的接口不启动，path必须以/开头，监听地址相同时path不能重复
+ * @return []serverEndpoint
+ * @return error
+*/
+func loadServerEndpoints() (endpoints []serverEndpoint, _err error) {
+	candidates := []serverEndpoint{
+		{key: "metrics", handler: promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{})},
+		{key: "http_sd", handler: http.HandlerFunc(httpSDHandler)},
+	}
+
+	seen := make(map[string]string)
+	for _, endpoint := range candidates {
+		endpoint.listen = viper.GetString(endpoint.key + ".listen")
+		endpoint.path = viper.GetString(endpoint.key + ".path")
+		if endpoint.listen == "" {
+			continue
+		}
+		if !strings.HasPrefix(endpoint.path, "/") {
+			return nil, fmt.Errorf("%s.path必须以/开头: %q", endpoint.key, endpoint.path)
+		}
+		// 同一个监听地址上重复注册同一个path时ServeMux会panic，提前作为配置错误返回
+		pattern := endpoint.listen + endpoint.path
+		if other, ok := seen[pattern]; ok {
+			return nil, fmt.Errorf("%s与%s的监听地址和path相同(%s)，请修改其中一个的path", endpoint.key, other, pattern)
+		}
+		seen[pattern] = endpoint.key
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints, nil
+}
+
+/**
+* 按metrics和http_sd配置启动常驻进程的HTTP服务，监听地址相同时共用一个服务，返回用于关闭服务的函数
+ * @return func(ctx context.Context)
+ * @return error
+*/
+func startServers() (stop func(ctx context.Context), _err error) {
+	endpoints, _err := loadServerEndpoints()
+	if _err != nil {
+		return nil, _err
+	}
+
+	var listens []string
+	muxes := make(map[string]*http.ServeMux)
+	for _, endpoint := range endpoints {
+		if _, ok := muxes[endpoint.listen]; !ok {
+			listens = append(listens, endpoint.listen)
+			muxes[endpoint.listen] = http.NewServeMux()
+		}
+		muxes[endpoint.listen].Handle(endpoint.path, endpoint.handler)
+		infologger.Printf("HTTP服务监听%s%s", endpoint.listen, endpoint.path)
+	}
+
+	var servers []*http.Server
+	for _, listen := range listens {
+		server := &http.Server{Addr: listen, Handler: muxes[listen]}
+		servers = append(servers, server)
+		go func() {
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				errlogger.Printf("HTTP服务%s异常: %v", server.Addr, err)
+			}
+		}()
+	}
+	return func(ctx context.Context) {
+		for _, server := range servers {
+			server.Shutdown(ctx)
+		}
+	}, nil
+}
@@ -0,0 +1,118 @@
+/**
+* Author: gongxiaoma
+* Date：2026-10-16
+ */
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+/**
+* 请求HTTP服务发现接口
+ * @param t
+ * @return *httptest.ResponseRecorder
+*/
+func requestHTTPSD(t *testing.T) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	httpSDHandler(recorder, httptest.NewRequest("GET", "/targets", nil))
+	return recorder
+}
+
+/**
+* 第一次生成目标前返回503，启动时从已有targets文件加载，探测后返回最新目标
+ * @param t
+*/
+func TestHTTPSDHandler(t *testing.T) {
+	targetGroupsMutex.Lock()
+	currentTargetGroups = nil
+	targetGroupsMutex.Unlock()
+	t.Cleanup(viper.Reset)
+
+	// 没有targets文件时返回503，Prometheus保留上一次的目标
+	viper.Set("targets.file", filepath.Join(t.TempDir(), "missing.yml"))
+	if err := seedTargetGroups(); err != nil {
+		t.Fatal(err)
+	}
+	if code := requestHTTPSD(t).Code; code != http.StatusServiceUnavailable {
+		t.Fatalf("第一次探测前状态码 = %d, want 503", code)
+	}
+
+	// 已有targets文件时启动后直接返回文件中的目标
+	file := filepath.Join(t.TempDir(), "targets.yml")
+	groups := []TargetGroup{{Targets: []string{"https://www.example.com"}, Labels: map[string]string{"team": "web"}}}
+	if err := WriteFileSD(file, "", groups); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("targets.file", file)
+	if err := seedTargetGroups(); err != nil {
+		t.Fatal(err)
+	}
+	recorder := requestHTTPSD(t)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("加载targets文件后状态码 = %d, want 200", recorder.Code)
+	}
+	if want := `[{"targets":["https://www.example.com"],"labels":{"team":"web"}}]`; recorder.Body.String() != want {
+		t.Fatalf("返回内容 = %s, want %s", recorder.Body.String(), want)
+	}
+
+	// 探测后没有目标时返回空列表
+	setTargetGroups(nil)
+	recorder = requestHTTPSD(t)
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "[]" {
+		t.Fatalf("没有目标时返回%d %s, want 200 []", recorder.Code, recorder.Body.String())
+	}
+}
+
+/**
+* metrics和http_sd使用相同的监听地址和path时返回配置错误，不能在启动时panic
+ * @param t
+*/
+func TestLoadServerEndpoints(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	// 监听地址相同、path不同时共用一个服务
+	viper.Set("metrics.listen", ":9219")
+	viper.Set("metrics.path", "/metrics")
+	viper.Set("http_sd.listen", ":9219")
+	viper.Set("http_sd.path", "/targets")
+	endpoints, err := loadServerEndpoints()
+	if err != nil || len(endpoints) != 2 {
+		t.Fatalf("loadServerEndpoints = %+v, %v", endpoints, err)
+	}
+
+	// 监听地址不同时path可以相同
+	viper.Set("http_sd.listen", ":9220")
+	viper.Set("http_sd.path", "/metrics")
+	if _, err := loadServerEndpoints(); err != nil {
+		t.Fatal(err)
+	}
+
+	// listen为空的接口不启动，也不参与重复检查
+	viper.Set("http_sd.listen", "")
+	if endpoints, err := loadServerEndpoints(); err != nil || len(endpoints) != 1 || endpoints[0].key != "metrics" {
+		t.Fatalf("loadServerEndpoints = %+v, %v", endpoints, err)
+	}
+
+	viper.Set("http_sd.listen", ":9219")
+	if _, err := loadServerEndpoints(); err == nil || !strings.Contains(err.Error(), "http_sd与metrics的监听地址和path相同") {
+		t.Fatalf("重复的path返回 %v", err)
+	}
+	if stop, err := startServers(); err == nil {
+		stop(context.Background())
+		t.Fatal("startServers没有返回重复path的错误")
+	}
+
+	viper.Set("http_sd.path", "targets")
+	if _, err := loadServerEndpoints(); err == nil || !strings.Contains(err.Error(), "http_sd.path必须以/开头") {
+		t.Fatalf("path格式错误返回 %v", err)
+	}
+}